@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	pAuth "github.com/tossaro/go-api-core/auth/proto"
+	cj "github.com/tossaro/go-api-core/jwt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	TokenAccess  = "access"
+	TokenRefresh = "refresh"
+	// TokenSystem marks principals created by background jobs.
+	TokenSystem = "system"
+)
+
+type (
+	// Principal is the authenticated caller, set once by the auth
+	// middlewares and read with PrincipalFrom in HTTP handlers, gRPC
+	// handlers and background jobs alike.
+	Principal struct {
+		UID uint64 `json:"uid"`
+		// Roles holds role ids as strings, tokens carry a single one.
+		Roles  []string `json:"roles"`
+		Scopes []string `json:"scopes,omitempty"`
+		Tenant string   `json:"tenant,omitempty"`
+		// SessionID is the refresh token key.
+		SessionID string                 `json:"session_id,omitempty"`
+		TokenType string                 `json:"token_type"`
+		Claims    map[string]interface{} `json:"-"`
+	}
+
+	principalKey struct{}
+)
+
+// WithPrincipal returns a copy of ctx carrying p, jobs use it to act on
+// behalf of a user or as TokenSystem.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal of ctx, false for anonymous calls.
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// FromJwt maps claims validated by jwt.Validate.
+func FromJwt(c *cj.TokenClaimsV1) *Principal {
+	p := &Principal{
+		UID:       c.UID,
+		Roles:     []string{strconv.FormatInt(int64(c.RoleId), 10)},
+		Tenant:    c.Tenant,
+		TokenType: c.Type,
+		Claims:    rawClaims(c),
+	}
+	if c.Key != nil {
+		p.SessionID = *(c.Key)
+	}
+	return p
+}
+
+// FromGrpc maps the claims returned by the auth service CheckV1.
+func FromGrpc(c *pAuth.TokenClaimsV1) *Principal {
+	return &Principal{
+		UID:       c.GetUid(),
+		Roles:     []string{strconv.FormatInt(int64(c.GetRid()), 10)},
+		TokenType: c.GetType(),
+		SessionID: c.GetKey(),
+		Claims:    rawClaims(c),
+	}
+}
+
+func rawClaims(c interface{}) map[string]interface{} {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil
+	}
+	m := make(map[string]interface{})
+	if json.Unmarshal(b, &m) != nil {
+		return nil
+	}
+	return m
+}
+
+// RoleID returns the first role as the int32 id found in tokens, 0 when
+// there is none.
+func (p *Principal) RoleID() int32 {
+	if len(p.Roles) == 0 {
+		return 0
+	}
+	id, _ := strconv.ParseInt(p.Roles[0], 10, 32)
+	return int32(id)
+}
+
+func (p *Principal) HasRole(id string) bool {
+	for _, r := range p.Roles {
+		if r == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) HasScope(s string) bool {
+	for _, v := range p.Scopes {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// UnaryServerInterceptor validates the access token of the authorization
+// metadata with j and stores its Principal for gRPC handlers.
+func UnaryServerInterceptor(j *cj.Jwt) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := grpcPrincipal(ctx, j)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func StreamServerInterceptor(j *cj.Jwt) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := grpcPrincipal(ss.Context(), j)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+func grpcPrincipal(ctx context.Context, j *cj.Jwt) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	v := md.Get("authorization")
+	if len(v) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization")
+	}
+	sa := strings.Split(v[0], " ")
+	if len(sa) != 2 {
+		return nil, status.Error(codes.Unauthenticated, "token malformed")
+	}
+	claims, err := j.Validate(sa[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if claims.Type != TokenAccess {
+		return nil, status.Error(codes.Unauthenticated, "token type missmatch")
+	}
+	return WithPrincipal(ctx, FromJwt(claims)), nil
+}
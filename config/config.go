@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -28,14 +29,42 @@ type Config struct {
 	}
 
 	HTTP struct {
-		Mode string
-		Port string
+		Mode        string
+		Port        string
+		Compression bool
+		MaxBodySize int64 //byte
+
+		CORS struct {
+			Enabled          bool
+			AllowOrigins     []string
+			AllowMethods     []string
+			AllowHeaders     []string
+			ExposeHeaders    []string
+			AllowCredentials bool
+			MaxAge           int //second
+		}
+
+		Security struct {
+			Enabled               bool
+			HSTSMaxAge            int //second
+			HSTSIncludeSubdomains bool
+			ContentSecurityPolicy string
+			FrameOptions          string
+			ReferrerPolicy        string
+		}
 	}
 
 	GRPC struct {
 		Port string
 	}
 
+	Tracing struct {
+		Provider    string
+		Endpoint    string
+		Insecure    bool
+		SampleRatio *float64
+	}
+
 	Log struct {
 		Type       string
 		Level      string
@@ -83,12 +112,97 @@ func New(f ...string) Config {
 	}
 	cfg.HTTP.Port = hPort
 
+	if hCompression, ok := os.LookupEnv("HTTP_COMPRESSION"); ok && hCompression != "" {
+		hCompressionBool, err := strconv.ParseBool(hCompression)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("convert HTTP_COMPRESSION failed: %v", err))
+		}
+		cfg.HTTP.Compression = hCompressionBool
+	}
+
+	if hMaxBody, ok := os.LookupEnv("HTTP_MAX_BODY_SIZE"); ok && hMaxBody != "" {
+		hMaxBodyIn, err := strconv.ParseInt(hMaxBody, 10, 64)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("convert HTTP_MAX_BODY_SIZE failed: %v", err))
+		}
+		cfg.HTTP.MaxBodySize = hMaxBodyIn
+	}
+
+	if cOrigins, ok := os.LookupEnv("HTTP_CORS_ORIGINS"); ok && cOrigins != "" {
+		cfg.HTTP.CORS.Enabled = true
+		cfg.HTTP.CORS.AllowOrigins = splitList(cOrigins)
+		cfg.HTTP.CORS.AllowMethods = splitList(os.Getenv("HTTP_CORS_METHODS"))
+		cfg.HTTP.CORS.AllowHeaders = splitList(os.Getenv("HTTP_CORS_HEADERS"))
+		cfg.HTTP.CORS.ExposeHeaders = splitList(os.Getenv("HTTP_CORS_EXPOSE_HEADERS"))
+
+		if cCredentials, ok := os.LookupEnv("HTTP_CORS_CREDENTIALS"); ok && cCredentials != "" {
+			cCredentialsBool, err := strconv.ParseBool(cCredentials)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("convert HTTP_CORS_CREDENTIALS failed: %v", err))
+			}
+			cfg.HTTP.CORS.AllowCredentials = cCredentialsBool
+		}
+
+		if cMaxAge, ok := os.LookupEnv("HTTP_CORS_MAX_AGE"); ok && cMaxAge != "" {
+			cMaxAgeIn, err := strconv.Atoi(cMaxAge)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("convert HTTP_CORS_MAX_AGE failed: %v", err))
+			}
+			cfg.HTTP.CORS.MaxAge = cMaxAgeIn
+		}
+	}
+
+	if sEnabled, ok := os.LookupEnv("HTTP_SECURITY_HEADERS"); ok && sEnabled != "" {
+		sEnabledBool, err := strconv.ParseBool(sEnabled)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("convert HTTP_SECURITY_HEADERS failed: %v", err))
+		}
+		cfg.HTTP.Security.Enabled = sEnabledBool
+
+		if sHsts, ok := os.LookupEnv("HTTP_HSTS_MAX_AGE"); ok && sHsts != "" {
+			sHstsIn, err := strconv.Atoi(sHsts)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("convert HTTP_HSTS_MAX_AGE failed: %v", err))
+			}
+			cfg.HTTP.Security.HSTSMaxAge = sHstsIn
+		}
+
+		if sSub, ok := os.LookupEnv("HTTP_HSTS_INCLUDE_SUBDOMAINS"); ok && sSub != "" {
+			sSubBool, err := strconv.ParseBool(sSub)
+			if err != nil {
+				log.Fatal(fmt.Sprintf("convert HTTP_HSTS_INCLUDE_SUBDOMAINS failed: %v", err))
+			}
+			cfg.HTTP.Security.HSTSIncludeSubdomains = sSubBool
+		}
+
+		cfg.HTTP.Security.ContentSecurityPolicy = os.Getenv("HTTP_CSP")
+		cfg.HTTP.Security.FrameOptions = os.Getenv("HTTP_FRAME_OPTIONS")
+		cfg.HTTP.Security.ReferrerPolicy = os.Getenv("HTTP_REFERRER_POLICY")
+	}
+
 	gPort, ok := os.LookupEnv("GRPC_PORT")
 	if !ok {
 		log.Fatal("env GRPC_PORT not provided")
 	}
 	cfg.GRPC.Port = gPort
 
+	cfg.Tracing.Provider = os.Getenv("TRACING_PROVIDER")
+	cfg.Tracing.Endpoint = os.Getenv("TRACING_ENDPOINT")
+	if tInsecure, ok := os.LookupEnv("TRACING_INSECURE"); ok && tInsecure != "" {
+		tInsecureBool, err := strconv.ParseBool(tInsecure)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("convert TRACING_INSECURE failed: %v", err))
+		}
+		cfg.Tracing.Insecure = tInsecureBool
+	}
+	if tRatio, ok := os.LookupEnv("TRACING_SAMPLE_RATIO"); ok && tRatio != "" {
+		tRatioFl, err := strconv.ParseFloat(tRatio, 64)
+		if err != nil {
+			log.Fatal(fmt.Sprintf("convert TRACING_SAMPLE_RATIO failed: %v", err))
+		}
+		cfg.Tracing.SampleRatio = &tRatioFl
+	}
+
 	logLevel, ok := os.LookupEnv("LOG_LEVEL")
 	if !ok {
 		log.Fatal("env LOG_LEVEL not provided")
@@ -161,3 +275,13 @@ func New(f ...string) Config {
 
 	return cfg
 }
+
+func splitList(v string) []string {
+	var r []string
+	for _, i := range strings.Split(v, ",") {
+		if i = strings.TrimSpace(i); i != "" {
+			r = append(r, i)
+		}
+	}
+	return r
+}
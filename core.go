@@ -1,12 +1,14 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	l "log"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/tossaro/go-api-core/config"
@@ -14,8 +16,14 @@ import (
 	"github.com/tossaro/go-api-core/httpserver"
 	j "github.com/tossaro/go-api-core/jwt"
 	"github.com/tossaro/go-api-core/logger"
+	"github.com/tossaro/go-api-core/policy"
+	"github.com/tossaro/go-api-core/rbac"
+	"github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tracing"
 )
 
+const _defaultTracingShutdown = 5 * time.Second
+
 type (
 	Options struct {
 		Config         config.Config
@@ -26,6 +34,14 @@ type (
 		PublicKeyPath  *string
 		I18n           *i18n.Bundle
 		Captcha        *bool
+		Redis          redis.Cacher
+		Clients        gin.ClientRegistry
+		HeaderPolicy   *gin.HeaderPolicy
+		ErrorFormat    string
+		Metrics        *gin.MetricsOptions
+		RBAC           *rbac.RBAC
+		Policy         *policy.Engine
+		Maintenance    *gin.MaintenanceOptions
 		Modules        []func([]interface{})
 		ModuleParams   []interface{}
 	}
@@ -45,14 +61,31 @@ func NewHttp(o Options) {
 		l.Fatal("gin - I18n option not provided")
 	}
 
+	tracer := tracing.New(tracing.NewOptions(o.Config))
+	tracing.SetDefault(tracer)
+
 	gOpt := gin.Options{
-		I18n:     o.I18n,
-		Mode:     o.Config.HTTP.Mode,
-		Version:  o.Config.App.Version,
-		BaseUrl:  o.Config.App.Name,
-		Log:      o.Log,
-		AuthType: o.AuthType,
-		Captcha:  o.Captcha,
+		I18n:         o.I18n,
+		Mode:         o.Config.HTTP.Mode,
+		Version:      o.Config.App.Version,
+		BaseUrl:      o.Config.App.Name,
+		Log:          o.Log,
+		AuthType:     o.AuthType,
+		Captcha:      o.Captcha,
+		Redis:        o.Redis,
+		Clients:      o.Clients,
+		HeaderPolicy: o.HeaderPolicy,
+		ErrorFormat:  o.ErrorFormat,
+		CORS:         gin.NewCORSOptions(o.Config),
+		Security:     gin.NewSecurityOptions(o.Config),
+		Compression:  gin.NewCompressionOptions(o.Config),
+		Metrics:      o.Metrics,
+		RBAC:         o.RBAC,
+		Policy:       o.Policy,
+		Maintenance:  o.Maintenance,
+	}
+	if o.Config.HTTP.MaxBodySize > 0 {
+		gOpt.MaxBodySize = &o.Config.HTTP.MaxBodySize
 	}
 
 	if o.AuthType == gin.AuthTypeGrpc {
@@ -102,6 +135,7 @@ func NewHttp(o Options) {
 	httpServer := httpserver.New(g.Gin, &httpserver.Options{
 		Port: &o.Config.HTTP.Port,
 	})
+	httpServer.RegisterOnShutdown(g.Shutdown)
 
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
@@ -118,4 +152,10 @@ func NewHttp(o Options) {
 	if err != nil {
 		o.Log.Error("core - shutdown http error: %s", err)
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), _defaultTracingShutdown)
+	defer cancel()
+	if err = tracer.Shutdown(ctx); err != nil {
+		o.Log.Error("core - shutdown tracing error: %s", err)
+	}
 }
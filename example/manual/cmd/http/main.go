@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
 	"strconv"
 	"syscall"
+	"time"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"github.com/tossaro/go-api-core/config"
@@ -17,6 +19,8 @@ import (
 	j "github.com/tossaro/go-api-core/jwt"
 	"github.com/tossaro/go-api-core/logger"
 	"github.com/tossaro/go-api-core/postgres"
+	"github.com/tossaro/go-api-core/rbac"
+	"github.com/tossaro/go-api-core/tracing"
 	"golang.org/x/text/language"
 )
 
@@ -59,6 +63,9 @@ func main() {
 		log.Fatal(fmt.Sprintf("convert POSTGRE_POOL_MAX failed: %v", err))
 	}
 
+	tracer := tracing.New(tracing.NewOptions(cfg))
+	tracing.SetDefault(tracer)
+
 	bI18n := i18n.NewBundle(language.English)
 	bI18n.RegisterUnmarshalFunc("json", json.Unmarshal)
 	bI18n.MustLoadMessageFile("./i18n/en.json")
@@ -77,7 +84,14 @@ func main() {
 		PublicKeyPath:        "./key_public.pem",
 	})
 
+	// permissions are read from role_permissions, a policy file works too:
+	// policy, err := rbac.LoadFile("./policy.yaml")
+	perm := rbac.New(&rbac.Options{
+		Store: &rbac.PostgresStore{Postgres: pg},
+	})
+
 	captcha := true
+	lang := "EN"
 	g := gin.New(&gin.Options{
 		I18n:     bI18n,
 		Mode:     cfg.HTTP.Mode,
@@ -89,7 +103,19 @@ func main() {
 		Jwt: jwt,
 		// if auth type grpc
 		// AuthService:  &cfg.Services[0].Url,
-		Captcha: &captcha,
+		Captcha:  &captcha,
+		RBAC:     perm,
+		CORS:     gin.NewCORSOptions(cfg),
+		Security: gin.NewSecurityOptions(cfg),
+		// compress responses and limit request bodies for every route
+		Compression: gin.NewCompressionOptions(cfg),
+		MaxBodySize: &cfg.HTTP.MaxBodySize,
+		HeaderPolicy: &gin.HeaderPolicy{
+			Rules: []gin.HeaderRule{
+				{Name: "x-request-lang", Required: true, Values: []string{"EN", "ID"}, Fallback: "Accept-Language", Default: &lang},
+				{Name: "x-request-key", Required: true},
+			},
+		},
 	})
 
 	http.NewModule1V1(g, pg)
@@ -112,4 +138,10 @@ func main() {
 	if err != nil {
 		log.Error("app - httpServer.Shutdown: %s", err)
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = tracer.Shutdown(ctx); err != nil {
+		log.Error("app - tracer.Shutdown: %s", err)
+	}
 }
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 
 	g "github.com/gin-gonic/gin"
@@ -8,16 +9,34 @@ import (
 	"github.com/tossaro/go-api-core/postgres"
 )
 
-type module1V1 struct {
-	gin *gin.Gin
-	pg  *postgres.Postgres
-}
+type (
+	module1V1 struct {
+		gin *gin.Gin
+		pg  *postgres.Postgres
+	}
+
+	api2ReqV1 struct {
+		ID   uint64 `uri:"id" binding:"required"`
+		Name string `json:"name" binding:"required,max=50"`
+	}
+
+	api2RespV1 struct {
+		ID   uint64 `json:"id"`
+		Name string `json:"name"`
+	}
+)
 
 func NewModule1V1(g *gin.Gin, pg *postgres.Postgres) {
 	m := &module1V1{g, pg}
 	h := g.Router.Group("module1")
 	{
 		h.GET("api1", m.api1)
+		gin.Register(g, h, gin.Operation{
+			Method:  http.MethodPut,
+			Path:    "api2/:id",
+			Summary: "API 2",
+			Tags:    []string{"Module 1"},
+		}, m.api2, g.RequirePermission("module1:write"))
 	}
 }
 
@@ -37,3 +56,7 @@ func NewModule1V1(g *gin.Gin, pg *postgres.Postgres) {
 func (m *module1V1) api1(c *g.Context) {
 	c.JSON(http.StatusOK, "API 1 Running")
 }
+
+func (m *module1V1) api2(ctx context.Context, req api2ReqV1) (api2RespV1, error) {
+	return api2RespV1{ID: req.ID, Name: req.Name}, nil
+}
@@ -0,0 +1,6 @@
+CREATE TABLE "role_permissions" (
+  "role_id" bigint NOT NULL REFERENCES "roles" ("id") ON DELETE CASCADE,
+  "permission" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("role_id", "permission")
+);
\ No newline at end of file
@@ -0,0 +1 @@
+INSERT INTO role_permissions (role_id, permission) VALUES (1, 'module1:read'), (2, 'module1:*');
\ No newline at end of file
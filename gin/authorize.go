@@ -0,0 +1,69 @@
+package gin
+
+import (
+	g "github.com/gin-gonic/gin"
+	ca "github.com/tossaro/go-api-core/auth"
+	"github.com/tossaro/go-api-core/policy"
+)
+
+// Authorize evaluates gin.Options.Policy for the caller doing action on
+// resource, the attributes loaded by the handler, and aborts with 403 when
+// denied. It reports whether the handler may continue:
+//
+//	if !m.gin.Authorize(c, "orders:edit", map[string]interface{}{"owner_id": o.UserID}) {
+//		return
+//	}
+func (gin *Gin) Authorize(c *g.Context, action string, resource map[string]interface{}) bool {
+	if gin.Options.Policy == nil {
+		gin.Options.Log.Error("gin - Authorize require Policy option")
+		gin.AppErrorResponse(c, ErrInternal)
+		return false
+	}
+
+	allowed, err := gin.Options.Policy.Allowed(c.Request.Context(), gin.policyInput(c, action, resource))
+	if err != nil {
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return false
+	}
+	if !allowed {
+		gin.AppErrorResponse(c, ErrForbidden)
+		return false
+	}
+	return true
+}
+
+func (gin *Gin) policyInput(c *g.Context, action string, resource map[string]interface{}) *policy.Input {
+	ctx := c.Request.Context()
+	subject := make(map[string]interface{})
+	if p, ok := ca.PrincipalFrom(ctx); ok {
+		subject["uid"] = p.UID
+		subject["role_id"] = p.RoleID()
+		subject["roles"] = p.Roles
+		subject["scopes"] = p.Scopes
+		subject["tenant"] = p.Tenant
+		subject["session_id"] = p.SessionID
+		subject["token_type"] = p.TokenType
+		subject["claims"] = p.Claims
+	}
+	if v := ctx.Value(CKey("client_key")); v != nil {
+		subject["client_key"] = v
+	}
+
+	params := make(map[string]interface{}, len(c.Params))
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+	return &policy.Input{
+		Subject:  subject,
+		Action:   action,
+		Resource: resource,
+		Request: map[string]interface{}{
+			"method":     c.Request.Method,
+			"path":       c.Request.URL.Path,
+			"route":      c.FullPath(),
+			"ip":         c.ClientIP(),
+			"params":     params,
+			"request_id": RequestIDFrom(ctx),
+		},
+	}
+}
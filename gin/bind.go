@@ -0,0 +1,240 @@
+package gin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+var (
+	ErrMalformedBody = NewAppError(http.StatusBadRequest, "malformed_body", "malformed_body", "Request body is malformed.")
+
+	validatorOnce sync.Once
+
+	// validationMessages are used when the I18n bundle has no
+	// "validation_<tag>" message, so every tag still reads naturally.
+	validationMessages = map[string]string{
+		"required":  "{{.Field}} is required.",
+		"email":     "{{.Field}} must be a valid email address.",
+		"min":       "{{.Field}} must be at least {{.Param}}.",
+		"max":       "{{.Field}} must be at most {{.Param}}.",
+		"len":       "{{.Field}} must be {{.Param}} in length.",
+		"gte":       "{{.Field}} must be greater than or equal to {{.Param}}.",
+		"lte":       "{{.Field}} must be less than or equal to {{.Param}}.",
+		"gt":        "{{.Field}} must be greater than {{.Param}}.",
+		"lt":        "{{.Field}} must be less than {{.Param}}.",
+		"oneof":     "{{.Field}} must be one of [{{.Param}}].",
+		"numeric":   "{{.Field}} must be numeric.",
+		"url":       "{{.Field}} must be a valid URL.",
+		"uuid":      "{{.Field}} must be a valid UUID.",
+		"datetime":  "{{.Field}} must match the format {{.Param}}.",
+		"type":      "{{.Field}} has an invalid value.",
+		"_fallback": "{{.Field}} is invalid.",
+	}
+)
+
+// bindTags are resolved in order so the field name reported to clients
+// matches the one they sent.
+var bindTags = []string{"json", "form", "uri", "header"}
+
+func registerValidator() {
+	validatorOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			for _, t := range bindTags {
+				name := strings.SplitN(f.Tag.Get(t), ",", 2)[0]
+				if name == "-" {
+					continue
+				}
+				if name != "" {
+					return name
+				}
+			}
+			return f.Name
+		})
+	})
+}
+
+func hasTag(t reflect.Type, tag string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if _, ok := f.Tag.Lookup(tag); ok {
+			return true
+		}
+		if f.Anonymous && hasTag(f.Type, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func headerMap(h http.Header) map[string][]string {
+	m := make(map[string][]string, len(h)*2)
+	for k, v := range h {
+		m[k] = v
+		m[strings.ToLower(k)] = v
+	}
+	return m
+}
+
+// ShouldBind maps path params (uri tag), headers (header tag), query
+// (form tag) and body (json or form) into obj and validates it once all
+// sources are mapped. Failures are returned as *AppError: ErrMalformedBody
+// or ErrValidation with one ErrorDetail per invalid field.
+func (gin *Gin) ShouldBind(c *g.Context, obj interface{}) error {
+	span, _ := tracing.StartSpan(c.Request.Context(), "ShouldBind", "custom")
+	defer span.End()
+	registerValidator()
+
+	t := reflect.TypeOf(obj)
+	if hasTag(t, "uri") {
+		m := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			m[p.Key] = []string{p.Value}
+		}
+		if err := gin.mapForm(c, obj, m, "uri"); err != nil {
+			return err
+		}
+	}
+	if hasTag(t, "header") {
+		if err := gin.mapForm(c, obj, headerMap(c.Request.Header), "header"); err != nil {
+			return err
+		}
+	}
+	if hasTag(t, "form") {
+		if err := gin.mapForm(c, obj, c.Request.URL.Query(), "form"); err != nil {
+			return err
+		}
+	}
+
+	if c.Request.Body != nil && c.Request.ContentLength != 0 {
+		switch c.ContentType() {
+		case binding.MIMEPOSTForm, binding.MIMEMultipartPOSTForm:
+			if err := c.Request.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+				return bodyError(err)
+			}
+			if err := gin.mapForm(c, obj, c.Request.PostForm, "form"); err != nil {
+				return err
+			}
+		default:
+			err := json.NewDecoder(c.Request.Body).Decode(obj)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return gin.bindError(c, err)
+			}
+		}
+	}
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return gin.validationError(c, err)
+	}
+	return nil
+}
+
+// Bind is ShouldBind aborting the request with the rendered error, it
+// reports whether the handler may continue.
+func (gin *Gin) Bind(c *g.Context, obj interface{}) bool {
+	if err := gin.ShouldBind(c, obj); err != nil {
+		gin.AppErrorResponse(c, err)
+		return false
+	}
+	return true
+}
+
+func (gin *Gin) bindError(c *g.Context, err error) error {
+	var ute *json.UnmarshalTypeError
+	if errors.As(err, &ute) {
+		return ErrValidation.Wrap(err).WithDetails(gin.typeDetail(c, ute.Field, ute.Type.String()))
+	}
+	return bodyError(err)
+}
+
+// mapForm maps m into obj, a conversion failure such as "?page=abc" is
+// reported on the key holding the invalid value.
+func (gin *Gin) mapForm(c *g.Context, obj interface{}, m map[string][]string, tag string) error {
+	err := binding.MapFormWithTag(obj, m, tag)
+	if err == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		probe := reflect.New(reflect.TypeOf(obj).Elem()).Interface()
+		if binding.MapFormWithTag(probe, map[string][]string{k: m[k]}, tag) != nil {
+			return ErrValidation.Wrap(err).WithDetails(gin.typeDetail(c, k, ""))
+		}
+	}
+	return ErrValidation.Wrap(err)
+}
+
+// typeDetail reports a value not converting to the type of field, the
+// field name is localized from its last path segment.
+func (gin *Gin) typeDetail(c *g.Context, field string, typ string) ErrorDetail {
+	name := field[strings.LastIndex(field, ".")+1:]
+	return ErrorDetail{
+		Field:        field,
+		Code:         "type",
+		MessageID:    "validation_type",
+		Message:      validationMessages["type"],
+		TemplateData: map[string]interface{}{"Field": gin.localize(c, "field_"+name, name, nil), "Param": typ},
+	}
+}
+
+func (gin *Gin) validationError(c *g.Context, err error) error {
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return ErrValidation.Wrap(err)
+	}
+
+	details := make([]ErrorDetail, 0, len(ves))
+	for _, fe := range ves {
+		field := fieldPath(fe)
+		msg, ok := validationMessages[fe.Tag()]
+		if !ok {
+			msg = validationMessages["_fallback"]
+		}
+		details = append(details, ErrorDetail{
+			Field:     field,
+			Code:      fe.Tag(),
+			MessageID: "validation_" + fe.Tag(),
+			Message:   msg,
+			TemplateData: map[string]interface{}{
+				"Field": gin.localize(c, "field_"+fe.Field(), fe.Field(), nil),
+				"Param": fe.Param(),
+				"Value": fe.Value(),
+			},
+		})
+	}
+	return ErrValidation.Wrap(err).WithDetails(details...)
+}
+
+// fieldPath drops the root struct name from the validator namespace,
+// "CreateReq.items[0].name" is reported as "items[0].name".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
@@ -0,0 +1,231 @@
+package gin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	HeaderCache = "X-Cache"
+
+	_defaultCachePrefix = "httpcache"
+	_cacheTagPrefix     = "httpcache-tag"
+)
+
+type (
+	CacheOptions struct {
+		TTL time.Duration
+		// PerUser adds user_id to the key, use it after AuthAccessMiddleware
+		// for responses depending on the caller.
+		PerUser bool
+		// Tags group cached responses for InvalidateCache, TagFunc adds
+		// request dependent tags such as "order:<id>".
+		Tags    []string
+		TagFunc func(c *g.Context) []string
+		Prefix  *string
+	}
+
+	cachedResponse struct {
+		Status      int       `json:"status"`
+		ContentType string    `json:"content_type"`
+		ETag        string    `json:"etag"`
+		Body        []byte    `json:"body"`
+		StoredAt    time.Time `json:"stored_at"`
+	}
+)
+
+// CacheMiddleware caches successful GET responses in gin.Options.Redis,
+// keyed by route, query, x-request-lang and optionally user. Every
+// response gets an ETag, If-None-Match is answered with 304. Clients may
+// skip the cache with Cache-Control: no-cache (refresh) or no-store.
+func (gin *Gin) CacheMiddleware(o *CacheOptions) g.HandlerFunc {
+	if gin.Options.Redis == nil {
+		gin.Options.Log.Fatal("gin - CacheMiddleware require Redis option")
+	}
+	if o.TTL <= 0 {
+		gin.Options.Log.Fatal("gin - CacheMiddleware require TTL option")
+	}
+	prefix := _defaultCachePrefix
+	if o.Prefix != nil {
+		prefix = *(o.Prefix)
+	}
+
+	return func(c *g.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		cc := strings.ToLower(c.GetHeader("Cache-Control"))
+		if strings.Contains(cc, "no-store") {
+			c.Next()
+			return
+		}
+
+		span, _ := tracing.StartSpan(c.Request.Context(), "CacheMiddleware", "custom")
+		key, err := gin.cacheKey(c, o)
+		if err != nil {
+			span.End()
+			gin.Options.Log.Error("cache", err)
+			c.Next()
+			return
+		}
+
+		if !strings.Contains(cc, "no-cache") {
+			v, err := gin.Options.Redis.WithContext(c.Request.Context()).Get(prefix, key)
+			if err != nil && !errors.Is(err, cr.Nil) {
+				gin.Options.Log.Error("cache", err)
+			}
+			var cached cachedResponse
+			if err == nil && json.Unmarshal([]byte(v), &cached) == nil {
+				span.End()
+				c.Header(HeaderCache, "HIT")
+				c.Header("Age", strconv.Itoa(int(time.Since(cached.StoredAt).Seconds())))
+				gin.writeCached(c, o, &cached)
+				c.Abort()
+				return
+			}
+		}
+		span.End()
+
+		w := newBufferWriter(c.Writer)
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		rcc := strings.ToLower(w.Header().Get("Cache-Control"))
+		if !w.wrote || w.Status() != http.StatusOK || strings.Contains(rcc, "no-store") || (strings.Contains(rcc, "private") && !o.PerUser) {
+			w.flush(nil)
+			return
+		}
+
+		cached := &cachedResponse{
+			Status:      w.Status(),
+			ContentType: w.Header().Get("Content-Type"),
+			ETag:        etag(w.body.Bytes()),
+			Body:        w.body.Bytes(),
+			StoredAt:    time.Now(),
+		}
+		if b, err := json.Marshal(cached); err == nil {
+			if err := gin.Options.Redis.WithContext(c.Request.Context()).Set(prefix, key, b, o.TTL); err != nil {
+				gin.Options.Log.Error("cache", err)
+			}
+		}
+
+		c.Header(HeaderCache, "MISS")
+		gin.writeCached(c, o, cached)
+	}
+}
+
+func (gin *Gin) writeCached(c *g.Context, o *CacheOptions, r *cachedResponse) {
+	h := c.Writer.Header()
+	h.Set("ETag", r.ETag)
+	if h.Get("Cache-Control") == "" {
+		cc := "max-age=" + strconv.Itoa(int(o.TTL.Seconds()))
+		if o.PerUser {
+			cc = "private, " + cc
+		}
+		h.Set("Cache-Control", cc)
+	}
+	h.Add("Vary", "x-request-lang")
+
+	if etagMatch(c.GetHeader("If-None-Match"), r.ETag) {
+		c.Writer.WriteHeader(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	if r.ContentType != "" {
+		h.Set("Content-Type", r.ContentType)
+	}
+	c.Writer.WriteHeader(r.Status)
+	if c.Request.Method == http.MethodHead {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+	_, _ = c.Writer.Write(r.Body)
+}
+
+// cacheKey hashes the request identity together with the current version
+// of every tag, so bumping a tag version invalidates all its entries.
+func (gin *Gin) cacheKey(c *g.Context, o *CacheOptions) (string, error) {
+	q := c.Request.URL.Query()
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(c.FullPath() + "\n" + c.Request.URL.Path + "\n")
+	for _, k := range keys {
+		vs := q[k]
+		sort.Strings(vs)
+		b.WriteString(k + "=" + strings.Join(vs, ",") + "&")
+	}
+	b.WriteString("\n" + strings.ToUpper(c.GetHeader("x-request-lang")))
+	if o.PerUser {
+		b.WriteString("\nuser:")
+		if p, ok := PrincipalFrom(c); ok {
+			b.WriteString(strconv.FormatUint(p.UID, 10))
+		}
+	}
+
+	tags := o.Tags
+	if o.TagFunc != nil {
+		tags = append(append([]string{}, tags...), o.TagFunc(c)...)
+	}
+	for _, t := range tags {
+		v, err := gin.Options.Redis.WithContext(c.Request.Context()).Get(_cacheTagPrefix, t)
+		if err != nil && !errors.Is(err, cr.Nil) {
+			return "", err
+		}
+		b.WriteString("\n" + t + "@" + v)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// InvalidateCache drops every cached response tagged with one of tags,
+// modules call it after writes with the request context so the tags of
+// its tenant are bumped.
+func (gin *Gin) InvalidateCache(ctx context.Context, tags ...string) error {
+	if gin.Options.Redis == nil {
+		return errors.New("gin - InvalidateCache require Redis option")
+	}
+	for _, t := range tags {
+		if _, err := gin.Options.Redis.WithContext(ctx).Incr(_cacheTagPrefix, t, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func etag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func etagMatch(header string, tag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+	return false
+}
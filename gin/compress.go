@@ -0,0 +1,278 @@
+package gin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	g "github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/tossaro/go-api-core/config"
+)
+
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+
+	_defaultCompressMinSize = 1024
+)
+
+var (
+	_defaultEncodings    = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+	_defaultCompressible = []string{"application/json", "application/problem+json", "application/xml", "application/javascript", "text/"}
+
+	gzipPool   = sync.Pool{New: func() interface{} { w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression); return w }}
+	brotliPool = sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression) }}
+	zstdPool   = sync.Pool{New: func() interface{} { w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1)); return w }}
+)
+
+type (
+	CompressionOptions struct {
+		// Encodings in server preference order, default br, zstd, gzip.
+		Encodings []string
+		// MinSize skips responses smaller than it, default 1024 bytes.
+		MinSize *int
+		// ContentTypes are compressed when the response Content-Type starts
+		// with one of them.
+		ContentTypes []string
+	}
+
+	encoder interface {
+		io.WriteCloser
+		Reset(w io.Writer)
+	}
+
+	// compressWriter holds the response until MinSize bytes are written,
+	// then either compresses or passes through everything. Flush decides
+	// right away so streamed responses are not delayed.
+	compressWriter struct {
+		g.ResponseWriter
+		o        *CompressionOptions
+		minSize  int
+		encoding string
+		status   int
+		buf      bytes.Buffer
+		enc      encoder
+		decided  bool
+		head     bool
+	}
+)
+
+func newEncoder(name string, w io.Writer) encoder {
+	var e encoder
+	switch name {
+	case EncodingBrotli:
+		e = brotliPool.Get().(*brotli.Writer)
+	case EncodingZstd:
+		e = zstdPool.Get().(*zstd.Encoder)
+	default:
+		e = gzipPool.Get().(*gzip.Writer)
+	}
+	e.Reset(w)
+	return e
+}
+
+func releaseEncoder(name string, e encoder) {
+	switch name {
+	case EncodingBrotli:
+		brotliPool.Put(e)
+	case EncodingZstd:
+		zstdPool.Put(e)
+	default:
+		gzipPool.Put(e)
+	}
+}
+
+// NewCompressionOptions maps HTTP_COMPRESSION, nil when disabled.
+func NewCompressionOptions(cfg config.Config) *CompressionOptions {
+	if !cfg.HTTP.Compression {
+		return nil
+	}
+	return &CompressionOptions{}
+}
+
+// negotiateEncoding picks the supported encoding with the highest q value
+// in Accept-Encoding, ties are broken by the server preference order.
+func negotiateEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	q := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		v := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				if p, err := strconv.ParseFloat(f[2:], 64); err == nil {
+					v = p
+				}
+			}
+		}
+		q[name] = v
+	}
+
+	best, bestQ := "", 0.0
+	for _, s := range supported {
+		v, ok := q[s]
+		if !ok {
+			v, ok = q["*"]
+		}
+		if ok && v > bestQ {
+			best, bestQ = s, v
+		}
+	}
+	return best
+}
+
+// CompressionMiddleware compresses responses with the encoding negotiated
+// from Accept-Encoding.
+func CompressionMiddleware(o *CompressionOptions) g.HandlerFunc {
+	encodings := o.Encodings
+	if len(encodings) == 0 {
+		encodings = _defaultEncodings
+	}
+	minSize := _defaultCompressMinSize
+	if o.MinSize != nil {
+		minSize = *(o.MinSize)
+	}
+
+	return func(c *g.Context) {
+		enc := negotiateEncoding(c.GetHeader("Accept-Encoding"), encodings)
+		if enc == "" || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			o:              o,
+			minSize:        minSize,
+			encoding:       enc,
+			status:         http.StatusOK,
+			head:           c.Request.Method == http.MethodHead,
+		}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		w.close()
+	}
+}
+
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || w.head {
+		return false
+	}
+	if w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		return false
+	}
+	ct := strings.ToLower(h.Get("Content-Type"))
+	if ct == "" || strings.HasPrefix(ct, "text/event-stream") {
+		return false
+	}
+	types := w.o.ContentTypes
+	if len(types) == 0 {
+		types = _defaultCompressible
+	}
+	for _, t := range types {
+		if strings.HasPrefix(ct, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *compressWriter) decide(compress bool) {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	h := w.Header()
+	h.Add("Vary", "Accept-Encoding")
+	if compress && w.compressible() {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		w.enc = newEncoder(w.encoding, w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() > 0 {
+		_, _ = w.write(w.buf.Bytes())
+		w.buf.Reset()
+	}
+}
+
+func (w *compressWriter) write(b []byte) (int, error) {
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if code > 0 && !w.decided {
+		w.status = code
+	}
+}
+
+func (w *compressWriter) WriteHeaderNow() {}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.decided {
+		return w.write(b)
+	}
+	w.buf.Write(b)
+	if w.buf.Len() >= w.minSize {
+		w.decide(true)
+	}
+	return len(b), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Status() int {
+	return w.status
+}
+
+func (w *compressWriter) Written() bool {
+	return w.decided || w.buf.Len() > 0
+}
+
+func (w *compressWriter) Flush() {
+	w.decide(true)
+	if f, ok := w.enc.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close sends what is still buffered, nothing is sent when the handler did
+// not respond so ErrorMiddleware can still render its error.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.buf.Len() == 0 && w.status == http.StatusOK {
+			return
+		}
+		w.decide(w.buf.Len() >= w.minSize)
+	}
+	if w.enc != nil {
+		_ = w.enc.Close()
+		releaseEncoder(w.encoding, w.enc)
+		w.enc = nil
+	}
+}
+
+// Unwrap lets http.ResponseController reach the connection, SSE extends
+// its write deadline.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,265 @@
+package gin
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/swaggo/swag"
+	"github.com/tossaro/go-api-core/openapi"
+)
+
+const (
+	_contractResponseLimit = 1 << 20
+	// _contractRequestLimit caps the body read for validation without
+	// BodyLimitMiddleware.
+	_contractRequestLimit = 10 << 20
+)
+
+var ErrContractViolation = NewAppError(http.StatusBadRequest, "contract_violation", "contract_violation", "Request does not match the API specification.")
+
+// contractTags maps the schema keyword of a violation to the validation tag
+// whose "validation_<tag>" message describes it.
+var contractTags = map[string]string{
+	"required":         "required",
+	"type":             "type",
+	"enum":             "oneof",
+	"minimum":          "min",
+	"minLength":        "min",
+	"minItems":         "min",
+	"maximum":          "max",
+	"maxLength":        "max",
+	"maxItems":         "max",
+	"exclusiveMinimum": "gt",
+	"exclusiveMaximum": "lt",
+}
+
+var contractFormats = map[string]string{
+	"email":     "email",
+	"uuid":      "uuid",
+	"uri":       "url",
+	"url":       "url",
+	"date-time": "datetime",
+	"date":      "datetime",
+}
+
+type ContractOptions struct {
+	// Document to enforce, see openapi.Load for the swagger.json files.
+	Document *openapi.Document
+	// DocumentName enforces a document published with Document, resolved
+	// on the first request once every route is registered.
+	DocumentName *string
+	// Responses validates json responses and logs their violations,
+	// default on outside release mode.
+	Responses *bool
+}
+
+// ContractMiddleware enforces an OpenAPI document: path, query and header
+// parameters and json bodies of the documented operations are validated
+// and rejected with ErrContractViolation, one localized detail per
+// violation. Without Document nor DocumentName the swag registered docs
+// are used. Responses are checked after the handler and mismatches logged,
+// they are already sent.
+func (gin *Gin) ContractMiddleware(o *ContractOptions) g.HandlerFunc {
+	responses := gin.Options.Mode != g.ReleaseMode
+	if o.Responses != nil {
+		responses = *(o.Responses)
+	}
+
+	var (
+		once sync.Once
+		doc  = o.Document
+	)
+	load := func() *openapi.Document {
+		once.Do(func() {
+			if doc != nil {
+				return
+			}
+			if o.DocumentName != nil {
+				d, ok := gin.OpenAPI(*(o.DocumentName))
+				if !ok {
+					gin.Options.Log.Fatal("gin - ContractMiddleware unknown DocumentName " + *(o.DocumentName))
+				}
+				doc = d
+				return
+			}
+			s, err := swag.ReadDoc()
+			if err != nil {
+				gin.Options.Log.Fatal("gin - ContractMiddleware require Document option: " + err.Error())
+			}
+			if doc, err = openapi.Parse([]byte(s)); err != nil {
+				gin.Options.Log.Fatal("gin - ContractMiddleware invalid swagger document: " + err.Error())
+			}
+		})
+		return doc
+	}
+
+	return func(c *g.Context) {
+		d := load()
+		op, params, ok := contractOperation(d, c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		vs, err := contractRequest(d, op, params, c, requestBodyLimit(c, _contractRequestLimit))
+		if err != nil {
+			gin.AppErrorResponse(c, err)
+			return
+		}
+		if len(vs) > 0 {
+			gin.AppErrorResponse(c, gin.contractError(c, vs))
+			return
+		}
+		// streams and upgraded connections are not json documents
+		if !responses || c.GetHeader("Upgrade") != "" || strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+
+		w := &bodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, limit: _contractResponseLimit}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		var body []byte
+		if !w.truncated {
+			body = w.body.Bytes()
+		}
+		for _, v := range contractResponse(d, op, c.Writer.Status(), c.Writer.Header().Get("Content-Type"), body) {
+			gin.Options.Log.Warn("gin - contract %s %s response %d: %s", c.Request.Method, c.FullPath(), c.Writer.Status(), v.Error())
+		}
+	}
+}
+
+// contractOperation finds the operation of the matched route, or of the
+// request path for documents whose paths differ from the route templates.
+func contractOperation(d *openapi.Document, c *g.Context) (*openapi.Operation, map[string]string, bool) {
+	if full := c.FullPath(); full != "" {
+		p, _ := documentPath(full)
+		if op, ok := d.Paths[p][strings.ToLower(c.Request.Method)]; ok {
+			params := make(map[string]string, len(c.Params))
+			for _, p := range c.Params {
+				params[p.Key] = strings.TrimPrefix(p.Value, "/")
+			}
+			return op, params, true
+		}
+	}
+	return d.Operation(c.Request.Method, c.Request.URL.Path)
+}
+
+func contractRequest(d *openapi.Document, op *openapi.Operation, params map[string]string, c *g.Context, limit int64) ([]openapi.Violation, error) {
+	var vs []openapi.Violation
+	query := c.Request.URL.Query()
+	for _, p := range op.Parameters {
+		var values []string
+		switch p.In {
+		case "path":
+			if v, ok := params[p.Name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = c.Request.Header.Values(p.Name)
+		default:
+			continue
+		}
+		vs = append(vs, d.ValidateParameter(p, values)...)
+	}
+
+	if op.RequestBody == nil {
+		return vs, nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || !strings.Contains(c.ContentType(), "json") && c.ContentType() != "" {
+		return vs, nil
+	}
+	var b []byte
+	if c.Request.Body != nil {
+		var err error
+		if b, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit)); err != nil {
+			return nil, bodyError(err)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		if op.RequestBody.Required {
+			vs = append(vs, openapi.Violation{Field: "body", Rule: "required"})
+		}
+		return vs, nil
+	}
+	v, err := openapi.Decode(b)
+	if err != nil {
+		return nil, ErrMalformedBody.Wrap(err)
+	}
+	return append(vs, d.Validate(media.Schema, v, "")...), nil
+}
+
+// contractResponse validates a json body against the response documented
+// for status, or the default one.
+func contractResponse(d *openapi.Document, op *openapi.Operation, status int, contentType string, b []byte) []openapi.Violation {
+	r, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if r, ok = op.Responses["default"]; !ok {
+			return []openapi.Violation{{Rule: "undocumented status", Param: strconv.Itoa(status)}}
+		}
+	}
+	if len(r.Content) == 0 || len(b) == 0 || !strings.Contains(contentType, "json") {
+		return nil
+	}
+	var media openapi.MediaType
+	for t, m := range r.Content {
+		if strings.Contains(t, "json") {
+			media = m
+			break
+		}
+	}
+	v, err := openapi.Decode(b)
+	if err != nil {
+		return []openapi.Violation{{Rule: "json", Param: err.Error()}}
+	}
+	return d.Validate(media.Schema, v, "")
+}
+
+func (gin *Gin) contractError(c *g.Context, vs []openapi.Violation) error {
+	details := make([]ErrorDetail, 0, len(vs))
+	for _, v := range vs {
+		tag := contractTags[v.Rule]
+		if v.Rule == "format" {
+			tag = contractFormats[v.Param]
+		}
+		if tag == "" {
+			tag = v.Rule
+		}
+		msg, ok := validationMessages[tag]
+		if !ok {
+			msg = validationMessages["_fallback"]
+		}
+
+		field := v.Field
+		if field == "" {
+			field = "body"
+		}
+		name := field
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err != nil {
+				name = name[i+1:]
+			}
+		}
+		details = append(details, ErrorDetail{
+			Field:     field,
+			Code:      tag,
+			MessageID: "validation_" + tag,
+			Message:   msg,
+			TemplateData: map[string]interface{}{
+				"Field": gin.localize(c, "field_"+name, name, nil),
+				"Param": v.Param,
+			},
+		})
+	}
+	return ErrContractViolation.WithDetails(details...)
+}
@@ -0,0 +1,200 @@
+package gin
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/config"
+)
+
+var (
+	_defaultCorsMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead}
+	_defaultCorsHeaders = []string{
+		"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization",
+		"x-request-lang", HeaderRequestKey, HeaderRequestTimestamp, HeaderRequestNonce, HeaderRequestSignature,
+		HeaderIdempotencyKey, HeaderRequestID,
+	}
+)
+
+type (
+	CORSOptions struct {
+		// AllowOrigins accepts "*" or wildcard subdomains such as
+		// "https://*.example.com".
+		AllowOrigins     []string
+		AllowMethods     []string
+		AllowHeaders     []string
+		ExposeHeaders    []string
+		AllowCredentials bool
+		// MaxAge lets browsers cache preflight responses.
+		MaxAge time.Duration
+	}
+
+	SecurityOptions struct {
+		// HSTSMaxAge enables Strict-Transport-Security when not zero.
+		HSTSMaxAge            time.Duration
+		HSTSIncludeSubdomains bool
+		HSTSPreload           bool
+		// ContentSecurityPolicy is not sent when empty, the swagger UI needs
+		// inline scripts and styles allowed.
+		ContentSecurityPolicy string
+		// FrameOptions default to DENY, ReferrerPolicy to no-referrer,
+		// use "-" to not send the header.
+		FrameOptions   string
+		ReferrerPolicy string
+		NoSniff        *bool
+	}
+)
+
+// NewCORSOptions maps the HTTP_CORS_* config, nil when disabled.
+func NewCORSOptions(cfg config.Config) *CORSOptions {
+	if !cfg.HTTP.CORS.Enabled {
+		return nil
+	}
+	return &CORSOptions{
+		AllowOrigins:     cfg.HTTP.CORS.AllowOrigins,
+		AllowMethods:     cfg.HTTP.CORS.AllowMethods,
+		AllowHeaders:     cfg.HTTP.CORS.AllowHeaders,
+		ExposeHeaders:    cfg.HTTP.CORS.ExposeHeaders,
+		AllowCredentials: cfg.HTTP.CORS.AllowCredentials,
+		MaxAge:           time.Duration(cfg.HTTP.CORS.MaxAge) * time.Second,
+	}
+}
+
+// NewSecurityOptions maps the HTTP_SECURITY_HEADERS config, nil when
+// disabled.
+func NewSecurityOptions(cfg config.Config) *SecurityOptions {
+	if !cfg.HTTP.Security.Enabled {
+		return nil
+	}
+	return &SecurityOptions{
+		HSTSMaxAge:            time.Duration(cfg.HTTP.Security.HSTSMaxAge) * time.Second,
+		HSTSIncludeSubdomains: cfg.HTTP.Security.HSTSIncludeSubdomains,
+		ContentSecurityPolicy: cfg.HTTP.Security.ContentSecurityPolicy,
+		FrameOptions:          cfg.HTTP.Security.FrameOptions,
+		ReferrerPolicy:        cfg.HTTP.Security.ReferrerPolicy,
+	}
+}
+
+func matchOrigin(patterns []string, origin string) bool {
+	for _, p := range patterns {
+		if p == "*" || strings.EqualFold(p, origin) {
+			return true
+		}
+		if i := strings.Index(p, "*"); i >= 0 {
+			prefix, suffix := p[:i], p[i+1:]
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+				strings.HasSuffix(strings.ToLower(origin), strings.ToLower(suffix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CORSMiddleware answers preflight requests and sets the CORS headers for
+// allowed origins, other origins get no CORS header at all.
+func CORSMiddleware(o *CORSOptions) g.HandlerFunc {
+	methods := o.AllowMethods
+	if len(methods) == 0 {
+		methods = _defaultCorsMethods
+	}
+	headers := o.AllowHeaders
+	if len(headers) == 0 {
+		headers = _defaultCorsHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	expose := strings.Join(o.ExposeHeaders, ", ")
+	wildcard := len(o.AllowOrigins) == 1 && o.AllowOrigins[0] == "*" && !o.AllowCredentials
+
+	return func(c *g.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !matchOrigin(o.AllowOrigins, origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if wildcard {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if o.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if expose != "" {
+				c.Header("Access-Control-Expose-Headers", expose)
+			}
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		c.Header("Access-Control-Allow-Methods", allowMethods)
+		c.Header("Access-Control-Allow-Headers", allowHeaders)
+		if o.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(int(o.MaxAge.Seconds())))
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func SecurityHeadersMiddleware(o *SecurityOptions) g.HandlerFunc {
+	var hsts string
+	if o.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(o.HSTSMaxAge.Seconds()))
+		if o.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if o.HSTSPreload {
+			hsts += "; preload"
+		}
+	}
+	frame := o.FrameOptions
+	if frame == "" {
+		frame = "DENY"
+	}
+	referrer := o.ReferrerPolicy
+	if referrer == "" {
+		referrer = "no-referrer"
+	}
+	noSniff := o.NoSniff == nil || *(o.NoSniff)
+
+	return func(c *g.Context) {
+		h := c.Writer.Header()
+		if hsts != "" {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		if o.ContentSecurityPolicy != "" {
+			h.Set("Content-Security-Policy", o.ContentSecurityPolicy)
+		}
+		if noSniff {
+			h.Set("X-Content-Type-Options", "nosniff")
+		}
+		if frame != "-" {
+			h.Set("X-Frame-Options", frame)
+		}
+		if referrer != "-" {
+			h.Set("Referrer-Policy", referrer)
+		}
+		c.Next()
+	}
+}
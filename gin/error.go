@@ -0,0 +1,235 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	ErrorFormatLegacy  = "legacy"
+	ErrorFormatProblem = "problem"
+
+	ContentTypeProblem = "application/problem+json"
+
+	_defaultProblemType = "about:blank"
+)
+
+type (
+	// AppError is an error carrying everything needed to render a response:
+	// a stable machine readable code, the http status and an i18n message id
+	// localized with the x-request-lang header.
+	AppError struct {
+		Code         string
+		Status       int
+		MessageID    string
+		Message      string
+		TemplateData map[string]interface{}
+		Details      []ErrorDetail
+		Err          error
+	}
+
+	ErrorDetail struct {
+		Field        string                 `json:"field,omitempty" example:"email"`
+		Code         string                 `json:"code" example:"required"`
+		Message      string                 `json:"message" example:"message"`
+		MessageID    string                 `json:"-"`
+		TemplateData map[string]interface{} `json:"-"`
+	}
+
+	Problem struct {
+		Type      string        `json:"type" example:"about:blank"`
+		Title     string        `json:"title" example:"Bad Request"`
+		Status    int           `json:"status" example:"400"`
+		Detail    string        `json:"detail,omitempty" example:"message"`
+		Instance  string        `json:"instance,omitempty" example:"/go-api-core/module1/api1"`
+		Code      string        `json:"code" example:"bad_request"`
+		Errors    []ErrorDetail `json:"errors,omitempty"`
+		RequestID string        `json:"request_id,omitempty" example:"0b5f3b8e-7c1e-4c55-9a8e-2f0d7c9b1a11"`
+	}
+)
+
+var (
+	ErrBadRequest   = NewAppError(http.StatusBadRequest, "bad_request", "bad_request", "Bad request.")
+	ErrUnauthorized = NewAppError(http.StatusUnauthorized, "unauthorized", "unauthorized", "Unauthorized.")
+	ErrExpired      = NewAppError(http.StatusExpectationFailed, "expired", "expired", "Your session has been ended. Please re-login.")
+	ErrForbidden    = NewAppError(http.StatusForbidden, "forbidden", "forbidden", "Forbidden.")
+	ErrNotFound     = NewAppError(http.StatusNotFound, "not_found", "not_found", "Resource not found.")
+	ErrConflict     = NewAppError(http.StatusConflict, "conflict", "conflict", "Resource conflict.")
+	ErrValidation   = NewAppError(http.StatusUnprocessableEntity, "validation_failed", "validation_failed", "Validation failed.")
+	ErrInternal     = NewAppError(http.StatusInternalServerError, "internal_error", "internal_error", "Internal server error")
+)
+
+func NewAppError(status int, code string, messageID string, message string) *AppError {
+	return &AppError{
+		Code:      code,
+		Status:    status,
+		MessageID: messageID,
+		Message:   message,
+	}
+}
+
+func (e *AppError) Error() string {
+	msg := e.Code
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any AppError with the same code, so errors.Is(err, ErrNotFound)
+// holds for copies returned by the With* helpers.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	if !ok {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+func (e *AppError) clone() *AppError {
+	c := *e
+	if e.TemplateData != nil {
+		c.TemplateData = make(map[string]interface{}, len(e.TemplateData))
+		for k, v := range e.TemplateData {
+			c.TemplateData[k] = v
+		}
+	}
+	c.Details = append([]ErrorDetail(nil), e.Details...)
+	return &c
+}
+
+func (e *AppError) WithData(d map[string]interface{}) *AppError {
+	c := e.clone()
+	if c.TemplateData == nil {
+		c.TemplateData = make(map[string]interface{}, len(d))
+	}
+	for k, v := range d {
+		c.TemplateData[k] = v
+	}
+	return c
+}
+
+func (e *AppError) WithDetails(d ...ErrorDetail) *AppError {
+	c := e.clone()
+	c.Details = append(c.Details, d...)
+	return c
+}
+
+func (e *AppError) Wrap(err error) *AppError {
+	c := e.clone()
+	c.Err = err
+	return c
+}
+
+func (gin *Gin) localizer(c *g.Context) *i18n.Localizer {
+	return i18n.NewLocalizer(gin.Options.I18n, c.GetHeader("x-request-lang"), c.GetHeader("Accept-Language"))
+}
+
+// localize falls back to msg when the bundle has no translation for id,
+// so services only need to translate the messages they care about.
+func (gin *Gin) localize(c *g.Context, id string, msg string, data map[string]interface{}) string {
+	if id == "" {
+		return msg
+	}
+	def := &i18n.Message{ID: id, Other: msg}
+	if msg == "" {
+		def.Other = id
+	}
+	loc, err := gin.localizer(c).Localize(&i18n.LocalizeConfig{
+		DefaultMessage: def,
+		TemplateData:   data,
+	})
+	if loc == "" {
+		loc = def.Other
+	}
+	var nf *i18n.MessageNotFoundErr
+	if err != nil && !errors.As(err, &nf) {
+		gin.Options.Log.Error("localize", err)
+	}
+	return loc
+}
+
+func (gin *Gin) errorFormat(c *g.Context) string {
+	if strings.Contains(c.GetHeader("Accept"), ContentTypeProblem) {
+		return ErrorFormatProblem
+	}
+	if gin.Options.ErrorFormat == ErrorFormatProblem {
+		return ErrorFormatProblem
+	}
+	return ErrorFormatLegacy
+}
+
+// AppErrorResponse aborts the request rendering err, any error which is not
+// an AppError is reported as ErrInternal, or ErrTimeout for an exceeded
+// context deadline.
+func (gin *Gin) AppErrorResponse(c *g.Context, err error) {
+	span, _ := tracing.StartSpan(c.Request.Context(), "AppErrorResponse", "error")
+	defer span.End()
+
+	var ae *AppError
+	if !errors.As(err, &ae) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			ae = ErrTimeout.Wrap(err)
+		} else {
+			ae = ErrInternal.Wrap(err)
+		}
+	}
+	rid := RequestIDFrom(c.Request.Context())
+	if ae.Status >= http.StatusInternalServerError {
+		gin.Options.Log.Error("error-response [" + rid + "]: " + err.Error())
+	}
+
+	msg := gin.localize(c, ae.MessageID, ae.Message, ae.TemplateData)
+	details := make([]ErrorDetail, len(ae.Details))
+	for i, d := range ae.Details {
+		details[i] = d
+		details[i].Message = gin.localize(c, d.MessageID, d.Message, d.TemplateData)
+	}
+
+	if gin.errorFormat(c) == ErrorFormatLegacy {
+		c.AbortWithStatusJSON(ae.Status, &Error{Message: msg, Code: ae.Code, Errors: details, RequestID: rid})
+		return
+	}
+
+	typ := _defaultProblemType
+	if gin.Options.ProblemTypeUrl != nil {
+		typ = strings.TrimSuffix(*gin.Options.ProblemTypeUrl, "/") + "/" + ae.Code
+	}
+	c.Header("Content-Type", ContentTypeProblem)
+	c.AbortWithStatusJSON(ae.Status, &Problem{
+		Type:      typ,
+		Title:     http.StatusText(ae.Status),
+		Status:    ae.Status,
+		Detail:    msg,
+		Instance:  c.Request.URL.Path,
+		Code:      ae.Code,
+		Errors:    details,
+		RequestID: rid,
+	})
+}
+
+// ErrorMiddleware renders the last error attached with c.Error when the
+// handler did not write a response by itself.
+func (gin *Gin) ErrorMiddleware() g.HandlerFunc {
+	return func(c *g.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		gin.AppErrorResponse(c, c.Errors.Last().Err)
+	}
+}
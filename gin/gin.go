@@ -3,17 +3,20 @@ package gin
 import (
 	"log"
 	"net/http"
+	"sync"
+	"time"
 
 	g "github.com/gin-gonic/gin"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
-	prm "github.com/prometheus/client_golang/prometheus/promhttp"
 	sf "github.com/swaggo/files"
 	gsw "github.com/swaggo/gin-swagger"
 	ch "github.com/tossaro/go-api-core/http"
 	cj "github.com/tossaro/go-api-core/jwt"
 	cl "github.com/tossaro/go-api-core/logger"
-	"go.elastic.co/apm"
-	"go.elastic.co/apm/module/apmgin"
+	"github.com/tossaro/go-api-core/policy"
+	"github.com/tossaro/go-api-core/rbac"
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tracing"
 )
 
 const (
@@ -25,14 +28,26 @@ type (
 	CKey string
 
 	Error struct {
-		Message string `json:"error" example:"message"`
+		Message   string        `json:"error" example:"message"`
+		Code      string        `json:"code,omitempty" example:"bad_request"`
+		Errors    []ErrorDetail `json:"errors,omitempty"`
+		RequestID string        `json:"request_id,omitempty" example:"0b5f3b8e-7c1e-4c55-9a8e-2f0d7c9b1a11"`
 	}
 
 	Gin struct {
 		Gin    *g.Engine
 		Router *g.RouterGroup
-		Jwt    *cj.Jwt
+		// Base is the BaseUrl group without header policy, see Group.
+		Base *g.RouterGroup
+		Jwt  *cj.Jwt
 		*Options
+
+		operations  []Operation
+		metrics     *metrics
+		docs        documents
+		maintenance *maintenance
+		closing     chan struct{}
+		closeOnce   sync.Once
 	}
 
 	Options struct {
@@ -45,6 +60,37 @@ type (
 		AuthService *string
 		Jwt         *cj.Jwt
 		Captcha     *bool
+		Redis       cr.Cacher
+		// Clients enables signature verification of x-request-key on the
+		// Router group, see SignatureMiddleware. It requires Redis to reject
+		// replayed nonces.
+		Clients       ClientRegistry
+		SignatureSkew *time.Duration
+		CORS          *CORSOptions
+		Security      *SecurityOptions
+		// HeaderPolicy applied on Router, DefaultHeaderPolicy when nil.
+		HeaderPolicy *HeaderPolicy
+		// ErrorFormat selects the AppError body, ErrorFormatLegacy (default)
+		// or ErrorFormatProblem for RFC 7807 application/problem+json.
+		ErrorFormat    string
+		ProblemTypeUrl *string
+		// Compression enables CompressionMiddleware on every route.
+		Compression *CompressionOptions
+		// MaxBodySize limits every request body when above zero. It is
+		// checked while the body is read, so a route BodyLimitMiddleware
+		// can raise it.
+		MaxBodySize *int64
+		Metrics     *MetricsOptions
+		// RBAC resolves the permissions checked by RequirePermission.
+		RBAC *rbac.RBAC
+		// Policy evaluates attribute based rules, see Authorize.
+		Policy *policy.Engine
+		// DocsUIUrl serves the swagger-ui-dist assets of /docs, default
+		// unpkg swagger-ui-dist@5 as the bundled UI predates OpenAPI 3.1.
+		DocsUIUrl *string
+		// Maintenance enables MaintenanceMiddleware on every route, it
+		// requires Redis.
+		Maintenance *MaintenanceOptions
 	}
 
 	TokenV1 struct {
@@ -78,59 +124,83 @@ func New(o *Options) *Gin {
 	if o.AuthType == AuthTypeJwt && o.Jwt == nil {
 		log.Fatal("gin - AuthTypeJwt require Jwt option")
 	}
+	if o.Clients != nil && o.Redis == nil {
+		log.Fatal("gin - Clients require Redis option")
+	}
+
+	if o.Metrics == nil {
+		o.Metrics = &MetricsOptions{}
+	}
 
 	g.SetMode(o.Mode)
 	r := g.Default()
-	r.Use(apmgin.Middleware(r))
+	gin := &Gin{Gin: r, Jwt: o.Jwt, Options: o, metrics: newMetrics(o.Metrics, o.BaseUrl), closing: make(chan struct{})}
+	r.Use(TracingMiddleware())
+	r.Use(RequestIDMiddleware())
+	r.Use(gin.MetricsMiddleware())
+	if o.CORS != nil {
+		r.Use(CORSMiddleware(o.CORS))
+	}
+	if o.Security != nil {
+		r.Use(SecurityHeadersMiddleware(o.Security))
+	}
+
+	if o.Compression != nil {
+		r.Use(CompressionMiddleware(o.Compression))
+	}
 
-	gin := &Gin{r, nil, o.Jwt, o}
+	r.Use(gin.ErrorMiddleware())
+	if o.MaxBodySize != nil && *(o.MaxBodySize) > 0 {
+		r.Use(gin.bodyLimit(*(o.MaxBodySize), false))
+	}
+	if o.Maintenance != nil {
+		r.Use(gin.MaintenanceMiddleware(o.Maintenance))
+	}
 
-	gRouter := r.Group(o.BaseUrl)
+	gBase := r.Group(o.BaseUrl)
 	{
-		gRouter.GET("/version", gin.version)
-		gRouter.GET("/metrics", g.WrapH(prm.Handler()))
-		gRouter.GET("/swagger/*any", gsw.DisablingWrapHandler(sf.Handler, "HTTP_SWAGGER_DISABLED"))
+		gBase.GET("/version", gin.version)
+		gBase.GET("/metrics", g.WrapH(gin.metrics.handler))
+		gBase.GET("/swagger/*any", gsw.DisablingWrapHandler(sf.Handler, SwaggerDisabledEnv))
+		gBase.GET(_docsPath+"/*any", gin.docsHandler())
+
+		if o.Maintenance != nil && len(o.Maintenance.AdminRoles) > 0 {
+			admin := gin.AuthAccessMiddleware(o.Maintenance.AdminRoles)
+			gBase.GET(_maintenancePath, admin, gin.maintenanceStatus)
+			gBase.PUT(_maintenancePath, admin, gin.maintenanceEnable)
+			gBase.DELETE(_maintenancePath, admin, gin.maintenanceDisable)
+		}
 
 		if o.Captcha != nil && *(o.Captcha) {
-			ch.NewCaptchaV1(gRouter, o.Log)
+			ch.NewCaptchaV1(gBase, o.Log)
 		}
 	}
 
-	gRouter.Use(validateHeader(gin))
-	gin.Router = gRouter
+	if o.HeaderPolicy == nil {
+		o.HeaderPolicy = DefaultHeaderPolicy()
+	}
+	gin.Base = gBase
+	gin.Router = r.Group(o.BaseUrl, gin.HeaderMiddleware(o.HeaderPolicy))
 	return gin
 }
 
-func validateHeader(gin *Gin) g.HandlerFunc {
-	return func(c *g.Context) {
-		span, _ := apm.StartSpan(c.Request.Context(), "ValidateHeader", "custom")
-		defer span.End()
-
-		localizer := i18n.NewLocalizer(gin.Options.I18n, c.GetHeader("x-request-lang"))
-		missHeader, err := localizer.LocalizeMessage(&i18n.Message{ID: "missing_header"})
-		if err != nil {
-			gin.Options.Log.Error("jwt-validate", err)
-			gin.ErrorResponse(c, http.StatusInternalServerError, "Internal server error")
-			return
-		}
-		l := c.GetHeader("x-request-lang")
-		if l == "" {
-			gin.ErrorResponse(c, http.StatusBadRequest, missHeader)
-			return
-		}
+// Shutdown ends the long lived responses, SSE streams and WebSocket hubs,
+// it is registered on the http server shutdown by core.
+func (gin *Gin) Shutdown() {
+	gin.closeOnce.Do(func() {
+		close(gin.closing)
+	})
+}
 
-		p := c.GetHeader("x-request-key")
-		if p == "" {
-			gin.ErrorResponse(c, http.StatusBadRequest, missHeader)
-			return
-		}
-	}
+// Done is closed by Shutdown.
+func (gin *Gin) Done() <-chan struct{} {
+	return gin.closing
 }
 
 func (gin *Gin) ErrorResponse(c *g.Context, code int, msg string) {
-	span, _ := apm.StartSpan(c.Request.Context(), "ErrorResponse", "error")
+	span, _ := tracing.StartSpan(c.Request.Context(), "ErrorResponse", "error")
 	defer span.End()
-	c.AbortWithStatusJSON(code, &Error{msg})
+	c.AbortWithStatusJSON(code, &Error{Message: msg, RequestID: RequestIDFrom(c.Request.Context())})
 }
 
 func (gin *Gin) AuthAccessMiddleware(rid []int32) g.HandlerFunc {
@@ -151,6 +221,7 @@ func (gin *Gin) authCheck(typ string, rid []int32) g.HandlerFunc {
 		default:
 			gin.checkSessionFromGrpc(c, typ, rid)
 		}
+		gin.authOutcome(c, typ)
 	}
 }
 
@@ -163,7 +234,7 @@ func (gin *Gin) authCheck(typ string, rid []int32) g.HandlerFunc {
 // @Success     200 {string} v1.0.0
 // @Router      /version [get]
 func (gin *Gin) version(c *g.Context) {
-	span, _ := apm.StartSpan(c.Request.Context(), "version", "request")
+	span, _ := tracing.StartSpan(c.Request.Context(), "version", "request")
 	defer span.End()
 	c.JSON(http.StatusOK, gin.Options.Version)
 }
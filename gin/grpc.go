@@ -9,16 +9,18 @@ import (
 	"time"
 
 	g "github.com/gin-gonic/gin"
+	ca "github.com/tossaro/go-api-core/auth"
 	pAuth "github.com/tossaro/go-api-core/auth/proto"
-	"go.elastic.co/apm"
+	"github.com/tossaro/go-api-core/tracing"
 
 	"github.com/nicksnyder/go-i18n/v2/i18n"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
 func (gin *Gin) checkSessionFromGrpc(c *g.Context, typ string, rid []int32) {
-	span, _ := apm.StartSpan(c.Request.Context(), "checkSessionFromGrpc", "custom")
+	span, _ := tracing.StartSpan(c.Request.Context(), "checkSessionFromGrpc", "custom")
 	defer span.End()
 
 	var err error
@@ -53,11 +55,21 @@ func (gin *Gin) checkSessionFromGrpc(c *g.Context, typ string, rid []int32) {
 	}
 	defer conn.Close()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	cSpan, cCtx := tracing.StartSpan(c.Request.Context(), "AuthServiceV1/CheckV1", tracing.KindGrpc)
+	md := metadata.MD{}
+	tracing.Inject(cCtx, tracing.MetadataCarrier(md))
+	if rid := RequestIDFrom(c.Request.Context()); rid != "" {
+		md.Set("x-request-id", rid)
+	}
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.Background(), md), time.Second)
 	defer cancel()
 
 	svc := pAuth.NewAuthServiceV1Client(conn)
 	resp, err := svc.CheckV1(ctx, &pAuth.CheckReqV1{Token: sa[1], Type: typ})
+	if err != nil {
+		cSpan.RecordError(err)
+	}
+	cSpan.End()
 	if err != nil {
 		status := http.StatusUnauthorized
 		message := unauthorizedLoc
@@ -91,11 +103,5 @@ func (gin *Gin) checkSessionFromGrpc(c *g.Context, typ string, rid []int32) {
 		}
 	}
 
-	ctx2 := context.WithValue(c.Request.Context(), CKey("user_id"), resp.GetUid())
-	ctx2 = context.WithValue(ctx2, CKey("user_role_id"), resp.GetRid())
-	if typ == "refresh" && resp.GetKey() != "" {
-		ctx2 = context.WithValue(ctx2, CKey("user_key"), resp.GetKey())
-	}
-	c.Request = c.Request.WithContext(ctx2)
-	c.Next()
+	setPrincipal(c, typ, ca.FromGrpc(resp), resp.GetKey())
 }
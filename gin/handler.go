@@ -0,0 +1,133 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"unicode"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+type (
+	// TypedHandler is a handler working on plain request and response
+	// types, see Handle.
+	TypedHandler[Req any, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+	// Operation describes a route registered through Register, it is kept
+	// on Gin to generate the API documents from the real handler types.
+	Operation struct {
+		ID          string
+		Method      string
+		Path        string
+		Summary     string
+		Description string
+		Tags        []string
+		Status      int
+		Request     reflect.Type
+		Response    reflect.Type
+	}
+
+	NoContent struct{}
+)
+
+// Handle adapts fn into a gin handler: the request is bound and validated
+// with ShouldBind, errors returned by fn are rendered with AppErrorResponse
+// and the response is written as json with the given status (200 when
+// omitted, no body when Resp is NoContent).
+func Handle[Req any, Resp any](gin *Gin, fn TypedHandler[Req, Resp], status ...int) g.HandlerFunc {
+	st := http.StatusOK
+	if len(status) > 0 {
+		st = status[0]
+	}
+	name := handlerName(fn)
+
+	return func(c *g.Context) {
+		span, ctx := tracing.StartSpan(c.Request.Context(), name, "handler")
+		defer span.End()
+
+		var req Req
+		if err := gin.ShouldBind(c, &req); err != nil {
+			gin.AppErrorResponse(c, err)
+			return
+		}
+
+		resp, err := fn(ctx, req)
+		if err != nil {
+			gin.AppErrorResponse(c, err)
+			return
+		}
+
+		if _, ok := any(resp).(NoContent); ok || st == http.StatusNoContent {
+			c.Status(st)
+			return
+		}
+		c.JSON(st, resp)
+	}
+}
+
+// Register adds fn to r under op.Method and op.Path and records op with
+// the Req and Resp types for the API documents.
+func Register[Req any, Resp any](gin *Gin, r *g.RouterGroup, op Operation, fn TypedHandler[Req, Resp], m ...g.HandlerFunc) {
+	if op.Method == "" {
+		gin.Options.Log.Fatal("gin - Register require Operation Method")
+	}
+	if op.Status == 0 {
+		op.Status = http.StatusOK
+	}
+	op.Method = strings.ToUpper(op.Method)
+	op.Request = reflect.TypeOf((*Req)(nil)).Elem()
+	op.Response = reflect.TypeOf((*Resp)(nil)).Elem()
+
+	handlers := append(append([]g.HandlerFunc{}, m...), Handle(gin, fn, op.Status))
+	r.Handle(op.Method, op.Path, handlers...)
+
+	op.Path = joinPath(r.BasePath(), op.Path)
+	if op.ID == "" {
+		op.ID = operationID(op.Method, op.Path)
+	}
+	gin.operations = append(gin.operations, op)
+}
+
+func (gin *Gin) Operations() []Operation {
+	return append([]Operation(nil), gin.operations...)
+}
+
+func joinPath(base string, rel string) string {
+	if rel == "" {
+		return base
+	}
+	p := path.Join(base, rel)
+	if strings.HasSuffix(rel, "/") && !strings.HasSuffix(p, "/") {
+		return p + "/"
+	}
+	return p
+}
+
+func handlerName(fn interface{}) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "Handle"
+	}
+	n := f.Name()
+	if i := strings.LastIndex(n, "/"); i >= 0 {
+		n = n[i+1:]
+	}
+	return n
+}
+
+// operationID turns "GET /api/orders/:id" into "getApiOrdersId".
+func operationID(method string, p string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, s := range strings.FieldsFunc(p, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		b.WriteString(strings.ToUpper(s[:1]) + s[1:])
+	}
+	return b.String()
+}
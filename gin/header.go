@@ -0,0 +1,143 @@
+package gin
+
+import (
+	"net/http"
+	"strings"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+var (
+	ErrMissingHeader = NewAppError(http.StatusBadRequest, "missing_header", "missing_header_name", "Missing required header {{.Header}}.")
+	ErrInvalidHeader = NewAppError(http.StatusBadRequest, "invalid_header", "invalid_header", "Header {{.Header}} must be one of {{.Values}}.")
+)
+
+type (
+	HeaderRule struct {
+		Name     string
+		Required bool
+		// Values restricts the header to these values, compared case
+		// insensitively, e.g. []string{"EN", "ID"} for x-request-lang.
+		Values []string
+		// Fallback is another header read when Name is missing, e.g.
+		// Accept-Language for x-request-lang, then Default is used.
+		Fallback string
+		Default  *string
+	}
+
+	// HeaderPolicy is checked by HeaderMiddleware, Exempt holds full route
+	// paths (as c.FullPath) skipping the policy, a trailing * matches a
+	// prefix, e.g. "/go-api-core/webhook/*".
+	HeaderPolicy struct {
+		Rules  []HeaderRule
+		Exempt []string
+		// SkipSignature disables signature verification of x-request-key
+		// when gin.Options.Clients is set.
+		SkipSignature bool
+	}
+)
+
+// DefaultHeaderPolicy is applied on Gin.Router when Options.HeaderPolicy is
+// not provided, both x-request-lang and x-request-key are required.
+func DefaultHeaderPolicy() *HeaderPolicy {
+	return &HeaderPolicy{
+		Rules: []HeaderRule{
+			{Name: "x-request-lang", Required: true},
+			{Name: "x-request-key", Required: true},
+		},
+	}
+}
+
+func (p *HeaderPolicy) exempt(path string) bool {
+	for _, e := range p.Exempt {
+		if e == path || (strings.HasSuffix(e, "*") && strings.HasPrefix(path, strings.TrimSuffix(e, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// Group creates a group under BaseUrl with its own header policy instead
+// of the one of Router, a nil policy means no header is required.
+func (gin *Gin) Group(relativePath string, p *HeaderPolicy, h ...g.HandlerFunc) *g.RouterGroup {
+	if p == nil {
+		p = &HeaderPolicy{SkipSignature: true}
+	}
+	return gin.Base.Group(relativePath, append([]g.HandlerFunc{gin.HeaderMiddleware(p)}, h...)...)
+}
+
+// HeaderMiddleware applies p, headers resolved from Fallback or Default
+// are set on the request so handlers and the localizer see them.
+func (gin *Gin) HeaderMiddleware(p *HeaderPolicy) g.HandlerFunc {
+	return func(c *g.Context) {
+		if p.exempt(c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		span, _ := tracing.StartSpan(c.Request.Context(), "HeaderMiddleware", "custom")
+		for _, r := range p.Rules {
+			v := c.GetHeader(r.Name)
+			if v == "" && r.Fallback != "" {
+				v = headerFallback(c.GetHeader(r.Fallback), r.Values)
+			}
+			if v == "" && r.Default != nil {
+				v = *(r.Default)
+			}
+			if v == "" {
+				if r.Required {
+					span.End()
+					gin.AppErrorResponse(c, ErrMissingHeader.WithData(map[string]interface{}{"Header": r.Name}))
+					return
+				}
+				continue
+			}
+			if len(r.Values) > 0 && !containsFold(r.Values, v) {
+				span.End()
+				gin.AppErrorResponse(c, ErrInvalidHeader.WithData(map[string]interface{}{
+					"Header": r.Name,
+					"Values": strings.Join(r.Values, ", "),
+				}))
+				return
+			}
+			c.Request.Header.Set(r.Name, v)
+		}
+		span.End()
+
+		if gin.Options.Clients != nil && !p.SkipSignature && !gin.verifySignature(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// headerFallback picks the first Accept-Language like entry ("id-ID,id;q=0.9")
+// allowed by values, matching either the full tag or its base language.
+func headerFallback(h string, values []string) string {
+	for _, part := range strings.Split(h, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		if len(values) == 0 {
+			return tag
+		}
+		base := strings.SplitN(tag, "-", 2)[0]
+		for _, v := range values {
+			if strings.EqualFold(v, tag) || strings.EqualFold(v, base) {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+func containsFold(s []string, v string) bool {
+	for _, i := range s {
+		if strings.EqualFold(i, v) {
+			return true
+		}
+	}
+	return false
+}
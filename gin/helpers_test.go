@@ -0,0 +1,153 @@
+package gin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tenant"
+	"golang.org/x/text/language"
+)
+
+type testLogger struct{}
+
+func (testLogger) Debug(message interface{}, args ...interface{}) {}
+func (testLogger) Info(message string, args ...interface{})       {}
+func (testLogger) Warn(message string, args ...interface{})       {}
+func (testLogger) Error(message interface{}, args ...interface{}) {}
+func (testLogger) Fatal(message interface{}, args ...interface{}) { panic(message) }
+
+// memRedis is an in memory Cacher, the copies returned by WithContext
+// share its keys.
+type memRedis struct {
+	*memStore
+	ctx context.Context
+}
+
+type memStore struct {
+	mu sync.Mutex
+	m  map[string]string
+}
+
+func newMemRedis() *memRedis {
+	return &memRedis{memStore: &memStore{m: map[string]string{}}}
+}
+
+func (r *memRedis) key(k string, p string) string {
+	if id, ok := tenant.From(r.ctx); ok {
+		return "tenant:" + id + ":" + k + ":" + p
+	}
+	return k + ":" + p
+}
+
+func (r *memRedis) Set(k string, p string, v interface{}, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m[r.key(k, p)] = memString(v)
+	return nil
+}
+
+func (r *memRedis) Delete(k string, p string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.m, r.key(k, p))
+	return nil
+}
+
+func (r *memRedis) Get(k string, p string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.m[r.key(k, p)]
+	if !ok {
+		return "", cr.Nil
+	}
+	return v, nil
+}
+
+func (r *memRedis) Ttl(k string, p string) (time.Duration, error) {
+	return 0, nil
+}
+
+func (r *memRedis) SetNX(k string, p string, v interface{}, d time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.m[r.key(k, p)]; ok {
+		return false, nil
+	}
+	r.m[r.key(k, p)] = memString(v)
+	return true, nil
+}
+
+func (r *memRedis) Incr(k string, p string, d time.Duration) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, _ := strconv.ParseInt(r.m[r.key(k, p)], 10, 64)
+	n++
+	r.m[r.key(k, p)] = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func (r *memRedis) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	return nil, errors.New("memRedis - Eval not supported")
+}
+
+func (r *memRedis) Publish(channel string, message interface{}) error {
+	return nil
+}
+
+func (r *memRedis) Subscribe(ctx context.Context, channels ...string) (<-chan string, error) {
+	return nil, errors.New("memRedis - Subscribe not supported")
+}
+
+func (r *memRedis) WithContext(ctx context.Context) cr.Cacher {
+	return &memRedis{memStore: r.memStore, ctx: ctx}
+}
+
+func memString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// newTestGin returns a Gin with the example translations, o overrides the
+// options required by New.
+func newTestGin(o *Options) *Gin {
+	b := i18n.NewBundle(language.English)
+	b.RegisterUnmarshalFunc("json", json.Unmarshal)
+	b.MustLoadMessageFile("../example/manual/i18n/en.json")
+	auth := "localhost:0"
+	o.I18n = b
+	o.Mode = "test"
+	o.Version = "test"
+	o.BaseUrl = "v1"
+	o.Log = testLogger{}
+	o.AuthType = AuthTypeGrpc
+	o.AuthService = &auth
+	return New(o)
+}
+
+func serve(gin *Gin, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	gin.Gin.ServeHTTP(w, r)
+	return w
+}
+
+func newRequest(method string, target string, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("x-request-key", "test")
+	return r
+}
@@ -0,0 +1,216 @@
+package gin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotent-Replayed"
+
+	_defaultIdempotencyPrefix  = "idempotency"
+	_defaultIdempotencyTTL     = 24 * time.Hour
+	_defaultIdempotencyLockTTL = time.Minute
+	_idempotencyPending        = "pending"
+	_idempotencyDone           = "done"
+	// _idempotencyBodyLimit caps the body read for the request hash
+	// without BodyLimitMiddleware.
+	_idempotencyBodyLimit = 10 << 20
+	// _idempotencyResponseLimit caps the stored response, larger ones are
+	// not stored and their retries run again.
+	_idempotencyResponseLimit = 1 << 20
+)
+
+// idempotencySkipHeaders are set by the writers on every response, a
+// replay gets them again.
+var idempotencySkipHeaders = []string{"Content-Encoding", "Content-Length", "Vary", http.CanonicalHeaderKey(HeaderRequestID)}
+
+var (
+	ErrIdempotencyInFlight = NewAppError(http.StatusConflict, "idempotency_in_flight", "idempotency_in_flight", "A request with the same Idempotency-Key is still being processed.")
+	ErrIdempotencyMismatch = NewAppError(http.StatusUnprocessableEntity, "idempotency_key_reused", "idempotency_key_reused", "Idempotency-Key was already used with a different request.")
+	ErrIdempotencyRequired = NewAppError(http.StatusBadRequest, "idempotency_key_required", "missing_header", "Missing required header parameters.")
+)
+
+type (
+	IdempotencyOptions struct {
+		// TTL keeps the stored response for replays, default 24 hours.
+		TTL *time.Duration
+		// LockTTL bounds how long a request is considered in flight, it
+		// should be longer than the slowest handler, default 1 minute.
+		LockTTL  *time.Duration
+		Required bool
+		Prefix   *string
+	}
+
+	idempotencyRecord struct {
+		State   string      `json:"state"`
+		Hash    string      `json:"hash"`
+		Status  int         `json:"status,omitempty"`
+		Headers http.Header `json:"headers,omitempty"`
+		Body    []byte      `json:"body,omitempty"`
+	}
+)
+
+// IdempotencyMiddleware stores the first response of a request carrying an
+// Idempotency-Key header in gin.Options.Redis and replays it for retries.
+// Keys are scoped by user_id, or x-request-key for anonymous routes, so
+// place it after AuthAccessMiddleware on authenticated routes.
+func (gin *Gin) IdempotencyMiddleware(o *IdempotencyOptions) g.HandlerFunc {
+	if gin.Options.Redis == nil {
+		gin.Options.Log.Fatal("gin - IdempotencyMiddleware require Redis option")
+	}
+	ttl := _defaultIdempotencyTTL
+	if o.TTL != nil {
+		ttl = *(o.TTL)
+	}
+	lTtl := _defaultIdempotencyLockTTL
+	if o.LockTTL != nil {
+		lTtl = *(o.LockTTL)
+	}
+	prefix := _defaultIdempotencyPrefix
+	if o.Prefix != nil {
+		prefix = *(o.Prefix)
+	}
+
+	return func(c *g.Context) {
+		ik := c.GetHeader(HeaderIdempotencyKey)
+		if ik == "" {
+			if o.Required {
+				gin.AppErrorResponse(c, ErrIdempotencyRequired)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		span, _ := tracing.StartSpan(c.Request.Context(), "IdempotencyMiddleware", "custom")
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, requestBodyLimit(c, _idempotencyBodyLimit)))
+		if err != nil {
+			span.End()
+			gin.AppErrorResponse(c, bodyError(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.RequestURI()+"\n"), body...))
+		hash := hex.EncodeToString(sum[:])
+		key := idempotencyScope(c) + ":" + ik
+
+		pending, _ := json.Marshal(&idempotencyRecord{State: _idempotencyPending, Hash: hash})
+		ok, err := gin.Options.Redis.WithContext(c.Request.Context()).SetNX(prefix, key, pending, lTtl)
+		if err != nil {
+			// without Redis we can not deduplicate, let the request through
+			span.End()
+			gin.Options.Log.Error("idempotency", err)
+			c.Next()
+			return
+		}
+		if !ok {
+			span.End()
+			gin.idempotencyReplay(c, prefix, key, hash)
+			return
+		}
+		span.End()
+
+		before := c.Writer.Header().Clone()
+		w := &bodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}, limit: _idempotencyResponseLimit}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		// server errors, errors rendered later by ErrorMiddleware and
+		// responses too large to store are released so the client can
+		// retry them
+		unanswered := w.body.Len() == 0 && c.Writer.Status() == http.StatusOK
+		if c.Writer.Status() >= http.StatusInternalServerError || len(c.Errors) > 0 || unanswered || w.truncated {
+			if err := gin.Options.Redis.WithContext(c.Request.Context()).Delete(prefix, key); err != nil {
+				gin.Options.Log.Error("idempotency", err)
+			}
+			return
+		}
+		rec, err := json.Marshal(&idempotencyRecord{
+			State:   _idempotencyDone,
+			Hash:    hash,
+			Status:  c.Writer.Status(),
+			Headers: handlerHeaders(before, c.Writer.Header()),
+			Body:    w.body.Bytes(),
+		})
+		if err == nil {
+			err = gin.Options.Redis.WithContext(c.Request.Context()).Set(prefix, key, rec, ttl)
+		}
+		if err != nil {
+			gin.Options.Log.Error("idempotency", err)
+		}
+	}
+}
+
+func (gin *Gin) idempotencyReplay(c *g.Context, prefix string, key string, hash string) {
+	v, err := gin.Options.Redis.WithContext(c.Request.Context()).Get(prefix, key)
+	if err != nil {
+		if errors.Is(err, cr.Nil) {
+			// the first request failed and released the key meanwhile
+			gin.AppErrorResponse(c, ErrIdempotencyInFlight)
+			return
+		}
+		gin.Options.Log.Error("idempotency", err)
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return
+	}
+
+	var rec idempotencyRecord
+	if err := json.Unmarshal([]byte(v), &rec); err != nil {
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return
+	}
+	if rec.Hash != hash {
+		gin.AppErrorResponse(c, ErrIdempotencyMismatch)
+		return
+	}
+	if rec.State != _idempotencyDone {
+		gin.AppErrorResponse(c, ErrIdempotencyInFlight)
+		return
+	}
+
+	for k, vs := range rec.Headers {
+		c.Writer.Header()[k] = vs
+	}
+	c.Header(HeaderIdempotencyReplayed, "true")
+	c.Status(rec.Status)
+	if len(rec.Body) > 0 {
+		_, _ = c.Writer.Write(rec.Body)
+	}
+	c.Abort()
+}
+
+// handlerHeaders returns the headers set after before was taken, those of
+// the middlewares are set again on every request.
+func handlerHeaders(before http.Header, after http.Header) http.Header {
+	h := http.Header{}
+	for k, vs := range after {
+		if containsString(idempotencySkipHeaders, http.CanonicalHeaderKey(k)) || reflect.DeepEqual(before[k], vs) {
+			continue
+		}
+		h[k] = append([]string{}, vs...)
+	}
+	return h
+}
+
+func idempotencyScope(c *g.Context) string {
+	if p, ok := PrincipalFrom(c); ok {
+		return fmt.Sprintf("user:%d", p.UID)
+	}
+	return "key:" + c.GetHeader("x-request-key")
+}
@@ -0,0 +1,77 @@
+package gin
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	g "github.com/gin-gonic/gin"
+)
+
+func idempotentRequest(key string) *http.Request {
+	r := newRequest(http.MethodPost, "/orders", `{"amount":10}`)
+	r.Header.Set(HeaderIdempotencyKey, key)
+	r.Header.Set("Origin", "https://app.example.com")
+	return r
+}
+
+func TestIdempotencyDoesNotStoreDeferredErrors(t *testing.T) {
+	gin := newTestGin(&Options{Redis: newMemRedis()})
+	calls := 0
+	gin.Gin.POST("/orders", gin.IdempotencyMiddleware(&IdempotencyOptions{}), func(c *g.Context) {
+		calls++
+		if calls == 1 {
+			_ = c.Error(ErrValidation)
+			return
+		}
+		c.JSON(http.StatusCreated, g.H{"id": calls})
+	})
+
+	if w := serve(gin, idempotentRequest("k1")); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("first status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+	if w := serve(gin, idempotentRequest("k1")); w.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("retry status = %d calls = %d, want %d after a new call", w.Code, calls, http.StatusCreated)
+	}
+	w := serve(gin, idempotentRequest("k1"))
+	if w.Code != http.StatusCreated || calls != 2 || w.Header().Get(HeaderIdempotencyReplayed) != "true" {
+		t.Fatalf("replay status = %d calls = %d, want the stored %d", w.Code, calls, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyReplaysHandlerHeadersOnly(t *testing.T) {
+	gin := newTestGin(&Options{Redis: newMemRedis(), CORS: &CORSOptions{AllowOrigins: []string{"*"}}})
+	gin.Gin.POST("/orders", gin.IdempotencyMiddleware(&IdempotencyOptions{}), func(c *g.Context) {
+		c.Header("Location", "/orders/1")
+		c.JSON(http.StatusCreated, g.H{"id": 1})
+	})
+
+	first := serve(gin, idempotentRequest("k2"))
+	w := serve(gin, idempotentRequest("k2"))
+	if w.Header().Get(HeaderIdempotencyReplayed) != "true" {
+		t.Fatal("second request was not replayed")
+	}
+	if v := w.Header().Values("Access-Control-Allow-Origin"); len(v) != 1 {
+		t.Errorf("Access-Control-Allow-Origin = %q, want one value", v)
+	}
+	if w.Header().Get(HeaderRequestID) == first.Header().Get(HeaderRequestID) {
+		t.Error("replay kept the request id of the first request")
+	}
+	if w.Header().Get("Location") != "/orders/1" || w.Body.String() != first.Body.String() {
+		t.Errorf("replay = %q %q, want the stored Location and body", w.Header().Get("Location"), w.Body.String())
+	}
+}
+
+func TestIdempotencyDoesNotStoreLargeResponses(t *testing.T) {
+	gin := newTestGin(&Options{Redis: newMemRedis()})
+	calls := 0
+	gin.Gin.POST("/orders", gin.IdempotencyMiddleware(&IdempotencyOptions{}), func(c *g.Context) {
+		calls++
+		c.String(http.StatusOK, strings.Repeat("a", _idempotencyResponseLimit+1))
+	})
+
+	serve(gin, idempotentRequest("k3"))
+	if w := serve(gin, idempotentRequest("k3")); w.Code != http.StatusOK || calls != 2 {
+		t.Fatalf("retry status = %d calls = %d, want a new call", w.Code, calls)
+	}
+}
@@ -1,7 +1,6 @@
 package gin
 
 import (
-	"context"
 	"errors"
 	"net/http"
 	"strconv"
@@ -9,6 +8,7 @@ import (
 
 	g "github.com/gin-gonic/gin"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
+	ca "github.com/tossaro/go-api-core/auth"
 )
 
 func (gin *Gin) checkSessionFromJwt(c *g.Context, typ string, rid []int32) {
@@ -74,11 +74,5 @@ func (gin *Gin) checkSessionFromJwt(c *g.Context, typ string, rid []int32) {
 		}
 	}
 
-	ctx := context.WithValue(c.Request.Context(), CKey("user_id"), claims.UID)
-	ctx = context.WithValue(ctx, CKey("user_role_id"), claims.RoleId)
-	if typ == "refresh" && claims.Key != nil {
-		ctx = context.WithValue(ctx, CKey("user_key"), claims.Key)
-	}
-	c.Request = c.Request.WithContext(ctx)
-	c.Next()
+	setPrincipal(c, typ, ca.FromJwt(claims), claims.Key)
 }
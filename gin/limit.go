@@ -0,0 +1,120 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+)
+
+const (
+	_rawBodyKey   = "_gin_raw_body"
+	_limitBodyKey = "_gin_limit_body"
+	_bodyLimitKey = "_gin_body_limit"
+)
+
+var (
+	ErrPayloadTooLarge    = NewAppError(http.StatusRequestEntityTooLarge, "payload_too_large", "payload_too_large", "Request body is too large, the limit is {{.Limit}} bytes.")
+	ErrTimeout            = NewAppError(http.StatusGatewayTimeout, "request_timeout", "request_timeout", "The request took too long to process, please try again.")
+	ErrServiceUnavailable = NewAppError(http.StatusServiceUnavailable, "service_unavailable", "service_unavailable", "Service is temporarily unavailable, please try again later.")
+)
+
+type TimeoutOptions struct {
+	Timeout time.Duration
+	// Error rendered once the deadline is exceeded, default ErrTimeout (504),
+	// ErrServiceUnavailable gives a 503.
+	Error *AppError
+}
+
+// BodyLimitMiddleware rejects request bodies larger than n bytes with 413.
+// A route limit replaces the global Options.MaxBodySize, higher or lower,
+// as long as the body was not read yet. Signature verification on Router
+// reads it, so routes needing a higher limit there should use Group with
+// their own limit.
+func (gin *Gin) BodyLimitMiddleware(n int64) g.HandlerFunc {
+	return gin.bodyLimit(n, true)
+}
+
+// bodyLimit without eager only limits the reads, the global limit leaves
+// the Content-Length check to a route limit coming later.
+func (gin *Gin) bodyLimit(n int64, eager bool) g.HandlerFunc {
+	return func(c *g.Context) {
+		if eager && c.Request.ContentLength > n {
+			gin.AppErrorResponse(c, ErrPayloadTooLarge.WithData(map[string]interface{}{"Limit": n}))
+			return
+		}
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		raw := c.Request.Body
+		if prev, ok := c.Get(_limitBodyKey); ok && prev == c.Request.Body {
+			raw = c.MustGet(_rawBodyKey).(io.ReadCloser)
+		}
+		body := http.MaxBytesReader(c.Writer, raw, n)
+		c.Set(_bodyLimitKey, n)
+		c.Set(_rawBodyKey, raw)
+		c.Set(_limitBodyKey, body)
+		c.Request.Body = body
+		c.Next()
+	}
+}
+
+// requestBodyLimit returns the limit of the BodyLimitMiddleware applied on
+// c, def without one.
+func requestBodyLimit(c *g.Context, def int64) int64 {
+	if n, ok := c.Get(_bodyLimitKey); ok {
+		return n.(int64)
+	}
+	return def
+}
+
+// bodyError reports a body read failure, exceeding BodyLimitMiddleware
+// gives ErrPayloadTooLarge and anything else ErrMalformedBody.
+func bodyError(err error) *AppError {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return ErrPayloadTooLarge.Wrap(err).WithData(map[string]interface{}{"Limit": mbe.Limit})
+	}
+	return ErrMalformedBody.Wrap(err)
+}
+
+// TimeoutMiddleware gives the handler a context deadline. Handlers are not
+// interrupted, they are expected to pass c.Request.Context() to Postgres,
+// Redis or gRPC calls which return once it is done. The response is held
+// until the handler returns and replaced by o.Error when the deadline was
+// exceeded, so do not use it on streaming routes.
+func (gin *Gin) TimeoutMiddleware(o *TimeoutOptions) g.HandlerFunc {
+	if o.Timeout <= 0 {
+		gin.Options.Log.Fatal("gin - TimeoutMiddleware require Timeout option")
+	}
+	e := ErrTimeout
+	if o.Error != nil {
+		e = o.Error
+	}
+
+	return func(c *g.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), o.Timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		w := newBufferWriter(c.Writer)
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			w.flush(nil)
+			return
+		}
+		if e.Status == http.StatusServiceUnavailable {
+			c.Header("Retry-After", strconv.Itoa(int(o.Timeout.Seconds())+1))
+		}
+		gin.AppErrorResponse(c, e.Wrap(ctx.Err()))
+	}
+}
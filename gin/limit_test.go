@@ -0,0 +1,44 @@
+package gin
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	g "github.com/gin-gonic/gin"
+)
+
+func TestBodyLimitRouteOverridesGlobal(t *testing.T) {
+	global := int64(1 << 10)
+	gin := newTestGin(&Options{MaxBodySize: &global})
+	read := func(c *g.Context) {
+		b, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			gin.AppErrorResponse(c, bodyError(err))
+			return
+		}
+		c.String(http.StatusOK, "%d", len(b))
+	}
+	gin.Gin.POST("/default", read)
+	gin.Gin.POST("/raised", gin.BodyLimitMiddleware(1<<20), read)
+	gin.Gin.POST("/lowered", gin.BodyLimitMiddleware(1<<8), read)
+
+	tests := []struct {
+		path string
+		size int
+		want int
+	}{
+		{"/default", 1 << 9, http.StatusOK},
+		{"/default", 10 << 10, http.StatusRequestEntityTooLarge},
+		{"/raised", 10 << 10, http.StatusOK},
+		{"/raised", 2 << 20, http.StatusRequestEntityTooLarge},
+		{"/lowered", 1 << 9, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := serve(gin, newRequest(http.MethodPost, tt.path, strings.Repeat("a", tt.size)))
+		if w.Code != tt.want {
+			t.Errorf("%s with %d bytes = %d, want %d", tt.path, tt.size, w.Code, tt.want)
+		}
+	}
+}
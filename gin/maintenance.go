@@ -0,0 +1,362 @@
+package gin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tenant"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	// MaintenanceReadOnly rejects the requests changing data, GET, HEAD and
+	// OPTIONS still pass.
+	MaintenanceReadOnly = "read_only"
+	// MaintenanceFull rejects every request.
+	MaintenanceFull = "full"
+
+	_defaultMaintenancePrefix     = "maintenance"
+	_defaultMaintenanceCacheTTL   = 2 * time.Second
+	_defaultMaintenanceRetryAfter = time.Minute
+	_maintenancePath              = "/maintenance"
+)
+
+var (
+	ErrMaintenance         = NewAppError(http.StatusServiceUnavailable, "maintenance", "maintenance", "The service is under maintenance, please try again later.")
+	ErrMaintenanceReadOnly = NewAppError(http.StatusServiceUnavailable, "maintenance_read_only", "maintenance_read_only", "The service is read-only during maintenance, please try again later.")
+)
+
+type (
+	MaintenanceOptions struct {
+		// Routes passing during maintenance, matched on the route path such
+		// as "/v1/health", a trailing "*" matches a prefix.
+		Routes []string
+		// IPs or CIDRs of the clients passing during maintenance.
+		IPs []string
+		// Roles passing during maintenance, their access token is checked
+		// by the middleware.
+		Roles []int32
+		// AdminRoles serves GET, PUT and DELETE <BaseUrl>/maintenance to
+		// read and toggle the mode, not served when empty.
+		AdminRoles []int32
+		// CacheTTL of the mode read from Redis by every replica, default 2s.
+		CacheTTL *time.Duration
+		// RetryAfter sent when the maintenance has no end, default 1 minute.
+		RetryAfter *time.Duration
+		Prefix     *string
+	}
+
+	MaintenanceMode struct {
+		Mode string `json:"mode" binding:"required,oneof=read_only full" example:"read_only"`
+		// Until ends the maintenance on its own, it lasts until disabled
+		// when empty.
+		Until *time.Time `json:"until,omitempty" example:"2024-01-01T00:00:00Z"`
+	}
+
+	maintenance struct {
+		o          *MaintenanceOptions
+		prefix     string
+		cacheTTL   time.Duration
+		retryAfter time.Duration
+		nets       []*net.IPNet
+
+		// state is served while a single request refreshes it, so a slow
+		// Redis does not hold every request.
+		state      atomic.Pointer[MaintenanceMode]
+		fetched    atomic.Int64
+		refreshing atomic.Bool
+	}
+)
+
+func (s *MaintenanceMode) active(now time.Time) bool {
+	return s != nil && (s.Until == nil || now.Before(*s.Until))
+}
+
+// blocks reports whether the mode rejects method.
+func (s *MaintenanceMode) blocks(method string) bool {
+	if s.Mode != MaintenanceReadOnly {
+		return true
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// MaintenanceMiddleware rejects requests with a 503 and Retry-After while
+// the maintenance mode set by SetMaintenance is on. The mode is kept in
+// gin.Options.Redis so it applies to every replica, each caching it for
+// o.CacheTTL. It is applied on every route by New with
+// gin.Options.Maintenance, the admin endpoint and /metrics always pass.
+func (gin *Gin) MaintenanceMiddleware(o *MaintenanceOptions) g.HandlerFunc {
+	if gin.Options.Redis == nil {
+		gin.Options.Log.Fatal("gin - MaintenanceMiddleware require Redis option")
+	}
+	m := &maintenance{
+		o:          o,
+		prefix:     _defaultMaintenancePrefix,
+		cacheTTL:   _defaultMaintenanceCacheTTL,
+		retryAfter: _defaultMaintenanceRetryAfter,
+	}
+	if o.Prefix != nil {
+		m.prefix = *(o.Prefix)
+	}
+	if o.CacheTTL != nil {
+		m.cacheTTL = *(o.CacheTTL)
+	}
+	if o.RetryAfter != nil {
+		m.retryAfter = *(o.RetryAfter)
+	}
+	for _, ip := range o.IPs {
+		n, err := parseIPNet(ip)
+		if err != nil {
+			gin.Options.Log.Fatal("gin - MaintenanceMiddleware invalid IPs option " + ip)
+		}
+		m.nets = append(m.nets, n)
+	}
+	gin.maintenance = m
+	// the routes of the BaseUrl group, their full path starts with "/"
+	base := joinPath(gin.Gin.BasePath(), gin.Options.BaseUrl)
+	allowed := []string{joinPath(base, _maintenancePath), joinPath(base, "/metrics")}
+
+	return func(c *g.Context) {
+		now := time.Now()
+		s := gin.maintenanceState(c.Request.Context(), now)
+		if !s.active(now) || !s.blocks(c.Request.Method) ||
+			containsString(allowed, c.FullPath()) || m.allowRoute(c.FullPath()) || m.allowIP(c.ClientIP()) {
+			c.Next()
+			return
+		}
+
+		// the role is only known from the token, auth middlewares run later
+		if len(o.Roles) > 0 && c.GetHeader("Authorization") != "" {
+			if _, ok := PrincipalFrom(c); !ok {
+				gin.authCheck("access", []int32{})(c)
+				if c.IsAborted() {
+					return
+				}
+			}
+			if p, ok := PrincipalFrom(c); ok && containsRole(o.Roles, p.RoleID()) {
+				c.Next()
+				return
+			}
+		}
+
+		retry := m.retryAfter
+		if s.Until != nil {
+			retry = s.Until.Sub(now)
+		}
+		c.Header("Retry-After", strconv.Itoa(max(1, ceilSeconds(retry))))
+		if s.Mode == MaintenanceReadOnly {
+			gin.AppErrorResponse(c, ErrMaintenanceReadOnly)
+			return
+		}
+		gin.AppErrorResponse(c, ErrMaintenance)
+	}
+}
+
+// GetMaintenance returns the maintenance mode read from Redis, nil when off.
+func (gin *Gin) GetMaintenance(ctx context.Context) (*MaintenanceMode, error) {
+	m := gin.maintenanceOrFatal()
+	// the mode is shared by every tenant
+	v, err := gin.Options.Redis.WithContext(tenant.With(ctx, "")).Get(m.prefix, gin.Options.BaseUrl)
+	if errors.Is(err, cr.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := &MaintenanceMode{}
+	if err := json.Unmarshal([]byte(v), s); err != nil {
+		return nil, err
+	}
+	if !s.active(time.Now()) {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// SetMaintenance turns the maintenance mode on for every replica, nil turns
+// it off. Other replicas see the change within CacheTTL.
+func (gin *Gin) SetMaintenance(ctx context.Context, s *MaintenanceMode) error {
+	m := gin.maintenanceOrFatal()
+	cache := gin.Options.Redis.WithContext(tenant.With(ctx, ""))
+	if s == nil {
+		if err := cache.Delete(m.prefix, gin.Options.BaseUrl); err != nil {
+			return err
+		}
+	} else {
+		var ttl time.Duration
+		if s.Until != nil {
+			if ttl = time.Until(*(s.Until)); ttl <= 0 {
+				return errors.New("maintenance - until is in the past")
+			}
+		}
+		b, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		if err := cache.Set(m.prefix, gin.Options.BaseUrl, b, ttl); err != nil {
+			return err
+		}
+	}
+
+	m.state.Store(s)
+	m.fetched.Store(time.Now().UnixNano())
+	return nil
+}
+
+func (gin *Gin) maintenanceOrFatal() *maintenance {
+	if gin.maintenance == nil {
+		gin.Options.Log.Fatal("gin - Maintenance require Maintenance option")
+	}
+	return gin.maintenance
+}
+
+// maintenanceState returns the cached mode. Once it is older than
+// CacheTTL one request reads it from Redis while the others keep the last
+// known one, which is also kept while Redis is unreachable.
+func (gin *Gin) maintenanceState(ctx context.Context, now time.Time) *MaintenanceMode {
+	m := gin.maintenance
+	s := m.state.Load()
+	if time.Duration(now.UnixNano()-m.fetched.Load()) < m.cacheTTL || !m.refreshing.CompareAndSwap(false, true) {
+		return s
+	}
+	defer m.refreshing.Store(false)
+
+	span, ctx := tracing.StartSpan(ctx, "MaintenanceMiddleware", "custom")
+	fresh, err := gin.GetMaintenance(ctx)
+	span.End()
+	m.fetched.Store(now.UnixNano())
+	if err != nil {
+		gin.Options.Log.Error("maintenance", err)
+		return s
+	}
+	m.state.Store(fresh)
+	return fresh
+}
+
+func (m *maintenance) allowRoute(route string) bool {
+	for _, r := range m.o.Routes {
+		if r == route || (strings.HasSuffix(r, "*") && strings.HasPrefix(route, strings.TrimSuffix(r, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *maintenance) allowIP(ip string) bool {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, n := range m.nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIPNet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, n, err := net.ParseCIDR(s)
+		return n, err
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.New("invalid ip " + s)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip, bits = ip.To4(), 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func containsRole(roles []int32, id int32) bool {
+	for _, r := range roles {
+		if r == id {
+			return true
+		}
+	}
+	return false
+}
+
+// @Summary     Get Maintenance
+// @Description Get the maintenance mode, null when off
+// @ID          maintenance-get
+// @Tags  	    API
+// @Produce     json
+// @Param       Authorization header string true "Bearer access token"
+// @Success     200 {object} MaintenanceMode
+// @Failure     401 {object} Error
+// @Router      /maintenance [get]
+func (gin *Gin) maintenanceStatus(c *g.Context) {
+	s, err := gin.GetMaintenance(c.Request.Context())
+	if err != nil {
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Set Maintenance
+// @Description Turn the maintenance mode on for every replica, until the optional end time
+// @ID          maintenance-set
+// @Tags  	    API
+// @Accept      json
+// @Produce     json
+// @Param       Authorization header string true "Bearer access token"
+// @Param       request body MaintenanceMode true "Maintenance mode"
+// @Success     200 {object} MaintenanceMode
+// @Failure     400 {object} Error
+// @Failure     401 {object} Error
+// @Router      /maintenance [put]
+func (gin *Gin) maintenanceEnable(c *g.Context) {
+	s := &MaintenanceMode{}
+	if !gin.Bind(c, s) {
+		return
+	}
+	if s.Until != nil && !s.Until.After(time.Now()) {
+		gin.AppErrorResponse(c, ErrValidation.WithDetails(ErrorDetail{
+			Field:        "until",
+			Code:         "gt",
+			MessageID:    "validation_gt",
+			Message:      validationMessages["gt"],
+			TemplateData: map[string]interface{}{"Field": gin.localize(c, "field_until", "until", nil), "Param": "now"},
+		}))
+		return
+	}
+	if err := gin.SetMaintenance(c.Request.Context(), s); err != nil {
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return
+	}
+	c.JSON(http.StatusOK, s)
+}
+
+// @Summary     Delete Maintenance
+// @Description Turn the maintenance mode off for every replica
+// @ID          maintenance-delete
+// @Tags  	    API
+// @Param       Authorization header string true "Bearer access token"
+// @Success     204
+// @Failure     401 {object} Error
+// @Router      /maintenance [delete]
+func (gin *Gin) maintenanceDisable(c *g.Context) {
+	if err := gin.SetMaintenance(c.Request.Context(), nil); err != nil {
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
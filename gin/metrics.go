@@ -0,0 +1,172 @@
+package gin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	prm "github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	AuthOutcomeSuccess      = "success"
+	AuthOutcomeExpired      = "expired"
+	AuthOutcomeForbidden    = "forbidden"
+	AuthOutcomeUnauthorized = "unauthorized"
+	AuthOutcomeError        = "error"
+
+	_unmatchedRoute = "unmatched"
+)
+
+type (
+	MetricsOptions struct {
+		// Namespace prefixes every metric, default BaseUrl which core sets
+		// to config.App.Name.
+		Namespace *string
+		// Registry collects the metrics and is served on /metrics, default
+		// the prometheus global registry.
+		Registry *prometheus.Registry
+		// Buckets of the latency histogram in seconds, default
+		// prometheus.DefBuckets.
+		Buckets []float64
+	}
+
+	metrics struct {
+		requests *prometheus.CounterVec
+		duration *prometheus.HistogramVec
+		inFlight *prometheus.GaugeVec
+		auth     *prometheus.CounterVec
+		versions *prometheus.CounterVec
+
+		registerer prometheus.Registerer
+		namespace  string
+		handler    http.Handler
+	}
+)
+
+// metricName turns s into a valid prometheus name part, app names such as
+// "go-api-core" become "go_api_core".
+func metricName(s string) string {
+	b := []byte(strings.Trim(s, "/"))
+	for i, c := range b {
+		if !(c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9' && i > 0)) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// register registers c, or returns the collector registered before under
+// the same name so New can be called more than once per process.
+func register[T prometheus.Collector](r prometheus.Registerer, c T) T {
+	if err := r.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if ex, ok := are.ExistingCollector.(T); ok {
+				return ex
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+func newMetrics(o *MetricsOptions, baseUrl string) *metrics {
+	ns := metricName(baseUrl)
+	if o.Namespace != nil {
+		ns = metricName(*(o.Namespace))
+	}
+	buckets := o.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	m := &metrics{
+		namespace:  ns,
+		registerer: prometheus.DefaultRegisterer,
+		handler:    prm.Handler(),
+	}
+	if o.Registry != nil {
+		m.registerer = o.Registry
+		m.handler = prm.HandlerFor(o.Registry, prm.HandlerOpts{Registry: o.Registry})
+	}
+
+	m.requests = register(m.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"}))
+	m.duration = register(m.registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: ns,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status.",
+		Buckets:   buckets,
+	}, []string{"route", "method", "status"}))
+	m.inFlight = register(m.registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: ns,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests being served by route and method.",
+	}, []string{"route", "method"}))
+	m.auth = register(m.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns,
+		Subsystem: "auth",
+		Name:      "checks_total",
+		Help:      "Authentication checks by token type and outcome.",
+	}, []string{"type", "outcome"}))
+	m.versions = register(m.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: ns,
+		Subsystem: "http",
+		Name:      "api_version_requests_total",
+		Help:      "Requests served by API version and route, deprecated versions flagged.",
+	}, []string{"version", "route", "deprecated"}))
+	return m
+}
+
+// MetricsMiddleware records the RED metrics of every request, routes are
+// labeled by their template so path params do not explode cardinality.
+func (gin *Gin) MetricsMiddleware() g.HandlerFunc {
+	return func(c *g.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = _unmatchedRoute
+		}
+		method := c.Request.Method
+
+		inFlight := gin.metrics.inFlight.WithLabelValues(route, method)
+		inFlight.Inc()
+		start := time.Now()
+		defer func() {
+			inFlight.Dec()
+			status := strconv.Itoa(c.Writer.Status())
+			gin.metrics.requests.WithLabelValues(route, method, status).Inc()
+			gin.metrics.duration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+		}()
+		c.Next()
+	}
+}
+
+// authOutcome counts the result of authCheck from the response it left,
+// a request still going on passed the check.
+func (gin *Gin) authOutcome(c *g.Context, typ string) {
+	outcome := AuthOutcomeSuccess
+	if c.IsAborted() {
+		switch c.Writer.Status() {
+		case http.StatusExpectationFailed:
+			outcome = AuthOutcomeExpired
+		case http.StatusForbidden:
+			outcome = AuthOutcomeForbidden
+		case http.StatusUnauthorized:
+			outcome = AuthOutcomeUnauthorized
+		default:
+			outcome = AuthOutcomeError
+		}
+	}
+	gin.metrics.auth.WithLabelValues(typ, outcome).Inc()
+}
@@ -0,0 +1,341 @@
+package gin
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/openapi"
+)
+
+const (
+	// SwaggerDisabledEnv turns /swagger and /docs off when set.
+	SwaggerDisabledEnv = "HTTP_SWAGGER_DISABLED"
+
+	_docsPath        = "/docs"
+	_defaultDocument = "api"
+	_defaultDocsUI   = "https://unpkg.com/swagger-ui-dist@5"
+)
+
+var docsIndex = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.UI}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.UI}}/swagger-ui-bundle.js"></script>
+<script src="{{.UI}}/swagger-ui-standalone-preset.js"></script>
+<script>
+window.onload = function() {
+	window.ui = SwaggerUIBundle({
+		urls: {{.Urls}},
+		dom_id: "#swagger-ui",
+		deepLinking: true,
+		presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+		layout: "StandaloneLayout"
+	});
+};
+</script>
+</body>
+</html>`))
+
+type (
+	DocumentOptions struct {
+		// Name of the document, served on /docs/openapi/<Name>.json.
+		Name        string
+		Title       *string
+		Description string
+		// Groups whose routes are documented, every route when empty.
+		Groups []*g.RouterGroup
+		// Parameters added to every operation, such as x-request-lang.
+		Parameters []openapi.Parameter
+		// BearerAuth declares the Authorization bearer token scheme.
+		BearerAuth bool
+	}
+
+	docsUrl struct {
+		Name string `json:"name"`
+		Url  string `json:"url"`
+	}
+
+	documents struct {
+		mu    sync.Mutex
+		opts  []*DocumentOptions
+		built map[string]*openapi.Document
+	}
+)
+
+// Document publishes an OpenAPI 3.1 document of the routes under o.Groups
+// on /docs, the UI lists every document. Operations added with Register
+// are described from their request and response types, other routes only
+// by method and path. Without any Document one covering every route is
+// served under the name "api".
+func (gin *Gin) Document(o *DocumentOptions) {
+	if o.Name == "" {
+		gin.Options.Log.Fatal("gin - Document require Name option")
+	}
+	gin.docs.mu.Lock()
+	defer gin.docs.mu.Unlock()
+	gin.docs.opts = append(gin.docs.opts, o)
+	gin.docs.built = nil
+}
+
+// OpenAPI returns the document published under name, generated on first
+// use once every route is registered.
+func (gin *Gin) OpenAPI(name string) (*openapi.Document, bool) {
+	gin.docs.mu.Lock()
+	defer gin.docs.mu.Unlock()
+	if gin.docs.built == nil {
+		gin.docs.built = make(map[string]*openapi.Document)
+	}
+	if d, ok := gin.docs.built[name]; ok {
+		return d, true
+	}
+	for _, o := range gin.documentOptions() {
+		if o.Name == name {
+			d := gin.buildDocument(o)
+			gin.docs.built[name] = d
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+func (gin *Gin) documentOptions() []*DocumentOptions {
+	if len(gin.docs.opts) == 0 {
+		return []*DocumentOptions{{Name: _defaultDocument, BearerAuth: true}}
+	}
+	return gin.docs.opts
+}
+
+// docsHandler serves the UI on /docs/ and the documents on
+// /docs/openapi/<name>.json, both off like /swagger with
+// HTTP_SWAGGER_DISABLED.
+func (gin *Gin) docsHandler() g.HandlerFunc {
+	if os.Getenv(SwaggerDisabledEnv) != "" {
+		return func(c *g.Context) {
+			c.String(http.StatusNotFound, "")
+		}
+	}
+	ui := _defaultDocsUI
+	if gin.Options.DocsUIUrl != nil {
+		ui = strings.TrimSuffix(*(gin.Options.DocsUIUrl), "/")
+	}
+
+	return func(c *g.Context) {
+		p := c.Param("any")
+		if p == "/" || p == "" {
+			gin.docs.mu.Lock()
+			opts := gin.documentOptions()
+			gin.docs.mu.Unlock()
+			urls := make([]docsUrl, len(opts))
+			for i, o := range opts {
+				urls[i] = docsUrl{Name: o.Name, Url: "openapi/" + o.Name + ".json"}
+			}
+			c.Status(http.StatusOK)
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			_ = docsIndex.Execute(c.Writer, map[string]interface{}{"Title": gin.Options.BaseUrl, "UI": ui, "Urls": urls})
+			return
+		}
+
+		name, ok := strings.CutPrefix(p, "/openapi/")
+		name, isJson := strings.CutSuffix(name, ".json")
+		if !ok || !isJson {
+			c.String(http.StatusNotFound, "")
+			return
+		}
+		d, ok := gin.OpenAPI(name)
+		if !ok {
+			c.String(http.StatusNotFound, "")
+			return
+		}
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+func (o *DocumentOptions) includes(path string) bool {
+	if len(o.Groups) == 0 {
+		return true
+	}
+	for _, r := range o.Groups {
+		base := strings.TrimSuffix(r.BasePath(), "/")
+		if path == base || strings.HasPrefix(path, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (gin *Gin) buildDocument(o *DocumentOptions) *openapi.Document {
+	title := gin.Options.BaseUrl
+	if o.Title != nil {
+		title = *(o.Title)
+	}
+	d := openapi.New(title, gin.Options.Version)
+	d.Info.Description = o.Description
+	gen := openapi.NewGenerator(d)
+
+	errType, errMedia := reflect.TypeOf(Error{}), "application/json"
+	if gin.Options.ErrorFormat == ErrorFormatProblem {
+		errType, errMedia = reflect.TypeOf(Problem{}), ContentTypeProblem
+	}
+	errResp := &openapi.Response{
+		Description: "Error",
+		Content:     map[string]openapi.MediaType{errMedia: {Schema: gen.Schema(errType)}},
+	}
+
+	if o.BearerAuth {
+		d.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		}
+		d.Security = []map[string][]string{{"bearerAuth": {}}}
+	}
+
+	seen := make(map[string]bool)
+	add := func(op Operation) {
+		if !o.includes(op.Path) {
+			return
+		}
+		seen[op.Method+" "+op.Path] = true
+		path, oo := gin.documentOperation(gen, op)
+		oo.Parameters = append(oo.Parameters, o.Parameters...)
+		oo.Responses["default"] = errResp
+		if d.Paths[path] == nil {
+			d.Paths[path] = make(openapi.PathItem)
+		}
+		d.Paths[path][strings.ToLower(op.Method)] = oo
+	}
+
+	for _, op := range gin.operations {
+		add(op)
+	}
+	docs := gin.Base.BasePath()
+	routes := gin.Gin.Routes()
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+	for _, r := range routes {
+		if seen[r.Method+" "+r.Path] || strings.HasPrefix(r.Path, joinPath(docs, _docsPath)) || strings.HasPrefix(r.Path, joinPath(docs, "/swagger")) {
+			continue
+		}
+		add(Operation{ID: operationID(r.Method, r.Path), Method: r.Method, Path: r.Path, Status: http.StatusOK})
+	}
+	return d
+}
+
+// documentOperation describes op, the request type is split like
+// ShouldBind maps it: uri fields are path parameters, header fields
+// headers, form fields the query and the rest the json body.
+func (gin *Gin) documentOperation(gen *openapi.Generator, op Operation) (string, *openapi.Operation) {
+	path, names := documentPath(op.Path)
+	oo := &openapi.Operation{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*openapi.Response),
+	}
+
+	inPath := make(map[string]bool)
+	var body bool
+	if t := op.Request; t != nil {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			for _, f := range requestFields(t) {
+				if in, name := parameterIn(f); in != "" {
+					s, required := gen.Field(f)
+					oo.Parameters = append(oo.Parameters, openapi.Parameter{Name: name, In: in, Required: required || in == "path", Schema: s})
+					if in == "path" {
+						inPath[name] = true
+					}
+					continue
+				}
+				body = true
+			}
+			if body && op.Method != http.MethodGet && op.Method != http.MethodHead && op.Method != http.MethodDelete {
+				var schema *openapi.Schema
+				if len(oo.Parameters) > 0 {
+					schema = gen.Named(t.Name()+"Body", t, isBodyField)
+				} else {
+					schema = gen.Schema(t)
+				}
+				oo.RequestBody = &openapi.RequestBody{
+					Required: true,
+					Content:  map[string]openapi.MediaType{"application/json": {Schema: schema}},
+				}
+			}
+		}
+	}
+	for _, n := range names {
+		if !inPath[n] {
+			oo.Parameters = append(oo.Parameters, openapi.Parameter{Name: n, In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}})
+		}
+	}
+
+	status := strconv.Itoa(op.Status)
+	resp := &openapi.Response{Description: http.StatusText(op.Status)}
+	if op.Response != nil && op.Response != reflect.TypeOf(NoContent{}) && op.Status != http.StatusNoContent {
+		resp.Content = map[string]openapi.MediaType{"application/json": {Schema: gen.Schema(op.Response)}}
+	}
+	oo.Responses[status] = resp
+	return path, oo
+}
+
+// requestFields flattens embedded structs carrying no tag of their own.
+func requestFields(t reflect.Type) []reflect.StructField {
+	var fs []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && f.Tag == "" {
+			fs = append(fs, requestFields(ft)...)
+			continue
+		}
+		if f.IsExported() && f.Tag.Get("json") != "-" {
+			fs = append(fs, f)
+		}
+	}
+	return fs
+}
+
+func parameterIn(f reflect.StructField) (string, string) {
+	for _, in := range [][2]string{{"uri", "path"}, {"header", "header"}, {"form", "query"}} {
+		if v, ok := f.Tag.Lookup(in[0]); ok {
+			if _, j := f.Tag.Lookup("json"); j && in[0] == "form" {
+				continue
+			}
+			return in[1], strings.SplitN(v, ",", 2)[0]
+		}
+	}
+	return "", ""
+}
+
+func isBodyField(f reflect.StructField) bool {
+	in, _ := parameterIn(f)
+	return in == ""
+}
+
+// documentPath turns "/orders/:id/*file" into "/orders/{id}/{file}".
+func documentPath(p string) (string, []string) {
+	var names []string
+	segs := strings.Split(p, "/")
+	for i, s := range segs {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			names = append(names, s[1:])
+			segs[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segs, "/"), names
+}
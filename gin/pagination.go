@@ -0,0 +1,337 @@
+package gin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/postgres"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	FieldString = "string"
+	FieldInt    = "int"
+	FieldFloat  = "float"
+	FieldBool   = "bool"
+	FieldTime   = "time"
+
+	_defaultListLimit    = 20
+	_defaultListMaxLimit = 100
+)
+
+var filterKey = regexp.MustCompile(`^filter\[(\w+)\](?:\[(\w+)\])?$`)
+
+type (
+	// ListField whitelists a query field for sorting and filtering, Column
+	// is the sql column name it maps to.
+	ListField struct {
+		Column   string
+		Type     string
+		Sortable bool
+		Ops      []string
+	}
+
+	ListOptions struct {
+		DefaultLimit *int
+		MaxLimit     *int
+		Fields       map[string]ListField
+		// DefaultSort uses the sort query syntax, e.g. []string{"-created_at", "id"}.
+		// With Cursor enabled, end it with a unique field to keep pages stable.
+		DefaultSort []string
+		Cursor      bool
+	}
+
+	// ListParams is parsed from the query string:
+	// ?page=2&limit=20 or ?cursor=...&limit=20,
+	// &sort=-created_at,name and &filter[status]=paid&filter[amount][gte]=10
+	ListParams struct {
+		Page    int
+		Limit   int
+		Cursor  string
+		Sorts   []postgres.Sort
+		Filters []postgres.Filter
+
+		sortFields []ListField
+		after      []interface{}
+		cursor     bool
+	}
+
+	PageMeta struct {
+		Page       int    `json:"page,omitempty" example:"1"`
+		Limit      int    `json:"limit" example:"20"`
+		Total      *int64 `json:"total,omitempty" example:"120"`
+		NextCursor string `json:"next_cursor,omitempty" example:"WzEwXQ"`
+	}
+
+	Paginated[T any] struct {
+		Data []T      `json:"data"`
+		Meta PageMeta `json:"meta"`
+	}
+)
+
+func listDetail(field string, code string, msg string, data map[string]interface{}) ErrorDetail {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["Field"] = field
+	return ErrorDetail{
+		Field:        field,
+		Code:         code,
+		MessageID:    "validation_" + code,
+		Message:      msg,
+		TemplateData: data,
+	}
+}
+
+// ShouldBindList parses pagination, sort and filter query parameters
+// against o, invalid values are returned as ErrValidation.
+func (gin *Gin) ShouldBindList(c *g.Context, o *ListOptions) (*ListParams, error) {
+	span, _ := tracing.StartSpan(c.Request.Context(), "ShouldBindList", "custom")
+	defer span.End()
+
+	dLimit := _defaultListLimit
+	if o.DefaultLimit != nil {
+		dLimit = *(o.DefaultLimit)
+	}
+	mLimit := _defaultListMaxLimit
+	if o.MaxLimit != nil {
+		mLimit = *(o.MaxLimit)
+	}
+
+	p := &ListParams{Page: 1, Limit: dLimit, cursor: o.Cursor}
+	var details []ErrorDetail
+	q := c.Request.URL.Query()
+
+	if v := q.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			details = append(details, listDetail("limit", "numeric", validationMessages["numeric"], nil))
+		case l < 1:
+			details = append(details, listDetail("limit", "min", validationMessages["min"], map[string]interface{}{"Param": 1}))
+		case l > mLimit:
+			details = append(details, listDetail("limit", "max", validationMessages["max"], map[string]interface{}{"Param": mLimit}))
+		default:
+			p.Limit = l
+		}
+	}
+	if v := q.Get("page"); v != "" && !o.Cursor {
+		pg, err := strconv.Atoi(v)
+		switch {
+		case err != nil:
+			details = append(details, listDetail("page", "numeric", validationMessages["numeric"], nil))
+		case pg < 1:
+			details = append(details, listDetail("page", "min", validationMessages["min"], map[string]interface{}{"Param": 1}))
+		default:
+			p.Page = pg
+		}
+	}
+
+	sorts := o.DefaultSort
+	if v := q.Get("sort"); v != "" {
+		sorts = strings.Split(v, ",")
+	}
+	for _, s := range sorts {
+		s = strings.TrimSpace(s)
+		desc := strings.HasPrefix(s, "-")
+		name := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+		f, ok := o.Fields[name]
+		if !ok || !f.Sortable {
+			details = append(details, listDetail("sort", "sort", "{{.Param}} is not a sortable field.", map[string]interface{}{"Param": name}))
+			continue
+		}
+		p.Sorts = append(p.Sorts, postgres.Sort{Column: f.Column, Desc: desc})
+		p.sortFields = append(p.sortFields, f)
+	}
+
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		vs := q[k]
+		m := filterKey.FindStringSubmatch(k)
+		if m == nil {
+			continue
+		}
+		name, op := m[1], m[2]
+		if op == "" {
+			op = postgres.OpEq
+		}
+		f, ok := o.Fields[name]
+		if !ok {
+			details = append(details, listDetail(k, "filter", "{{.Field}} is not a supported filter.", nil))
+			continue
+		}
+		if !containsString(f.Ops, op) {
+			details = append(details, listDetail(k, "filter_op", "{{.Field}} does not support operator {{.Param}}.", map[string]interface{}{"Param": op}))
+			continue
+		}
+
+		v, err := parseFilter(f.Type, op, vs[len(vs)-1])
+		if err != nil {
+			details = append(details, listDetail(k, "type", validationMessages["type"], map[string]interface{}{"Param": f.Type}))
+			continue
+		}
+		p.Filters = append(p.Filters, postgres.Filter{Column: f.Column, Op: op, Value: v})
+	}
+
+	if v := q.Get("cursor"); v != "" && o.Cursor {
+		after, err := decodeCursor(v, p.sortFields)
+		if err != nil {
+			details = append(details, listDetail("cursor", "cursor", "{{.Field}} is invalid.", nil))
+		} else {
+			p.Cursor = v
+			p.after = after
+		}
+	}
+
+	if len(details) > 0 {
+		return nil, ErrValidation.WithDetails(details...)
+	}
+	return p, nil
+}
+
+// BindList is ShouldBindList aborting the request with the rendered error.
+func (gin *Gin) BindList(c *g.Context, o *ListOptions) (*ListParams, bool) {
+	p, err := gin.ShouldBindList(c, o)
+	if err != nil {
+		gin.AppErrorResponse(c, err)
+		return nil, false
+	}
+	return p, true
+}
+
+func (p *ListParams) Query() *postgres.ListQuery {
+	q := &postgres.ListQuery{
+		Filters: p.Filters,
+		Sorts:   p.Sorts,
+		Limit:   p.Limit,
+		After:   p.after,
+	}
+	if !p.cursor {
+		q.Offset = (p.Page - 1) * p.Limit
+	}
+	return q
+}
+
+// NextCursor encodes the sort values of the last returned row, they must
+// be given in the same order as Sorts and not be nil.
+func (p *ListParams) NextCursor(values ...interface{}) string {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// NewPaginated wraps data in the standard list envelope, total and next
+// are optional (nil and "" are omitted).
+func NewPaginated[T any](p *ListParams, data []T, total *int64, next string) Paginated[T] {
+	if data == nil {
+		data = []T{}
+	}
+	m := PageMeta{Limit: p.Limit, Total: total, NextCursor: next}
+	if !p.cursor {
+		m.Page = p.Page
+	}
+	return Paginated[T]{Data: data, Meta: m}
+}
+
+func decodeCursor(v string, fields []ListField) ([]interface{}, error) {
+	b, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, err
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var raw []interface{}
+	if err := d.Decode(&raw); err != nil {
+		return nil, err
+	}
+	if len(raw) == 0 || len(raw) > len(fields) {
+		return nil, fmt.Errorf("cursor has %d values for %d sorts", len(raw), len(fields))
+	}
+	after := make([]interface{}, len(raw))
+	for i, r := range raw {
+		// keyset comparisons never match NULL, sort columns must be NOT NULL
+		if r == nil {
+			return nil, fmt.Errorf("cursor value %d is null", i)
+		}
+		after[i], err = parseValue(fields[i].Type, fmt.Sprint(r))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return after, nil
+}
+
+func parseFilter(typ string, op string, v string) (interface{}, error) {
+	switch op {
+	case postgres.OpIsNull:
+		return strconv.ParseBool(v)
+	case postgres.OpLike:
+		return v, nil
+	case postgres.OpIn:
+		// every element is typed like a single value, pgx sends the array
+		// type of the column then
+		parts := strings.Split(v, ",")
+		switch typ {
+		case FieldInt:
+			return parseList(parts, func(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) })
+		case FieldFloat:
+			return parseList(parts, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+		case FieldBool:
+			return parseList(parts, strconv.ParseBool)
+		case FieldTime:
+			return parseList(parts, func(s string) (time.Time, error) { return time.Parse(time.RFC3339, s) })
+		default:
+			return parseList(parts, func(s string) (string, error) { return s, nil })
+		}
+	}
+	return parseValue(typ, v)
+}
+
+func parseList[T any](parts []string, parse func(string) (T, error)) ([]T, error) {
+	r := make([]T, len(parts))
+	for i, s := range parts {
+		v, err := parse(strings.TrimSpace(s))
+		if err != nil {
+			return nil, err
+		}
+		r[i] = v
+	}
+	return r, nil
+}
+
+func parseValue(typ string, v string) (interface{}, error) {
+	switch typ {
+	case FieldInt:
+		return strconv.ParseInt(v, 10, 64)
+	case FieldFloat:
+		return strconv.ParseFloat(v, 64)
+	case FieldBool:
+		return strconv.ParseBool(v)
+	case FieldTime:
+		return time.Parse(time.RFC3339, v)
+	}
+	return v, nil
+}
+
+func containsString(s []string, v string) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
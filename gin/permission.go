@@ -0,0 +1,45 @@
+package gin
+
+import (
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+// RequirePermission allows the request only when the caller's role holds
+// every permission in gin.Options.RBAC. The access token is checked first
+// unless AuthAccessMiddleware already did, so it can be used alone:
+//
+//	h.POST("orders", gin.RequirePermission("orders:write"), m.create)
+func (gin *Gin) RequirePermission(perms ...string) g.HandlerFunc {
+	if gin.Options.RBAC == nil {
+		gin.Options.Log.Fatal("gin - RequirePermission require RBAC option")
+	}
+	check := gin.authCheck("access", []int32{})
+
+	return func(c *g.Context) {
+		p, ok := PrincipalFrom(c)
+		if !ok {
+			check(c)
+			if c.IsAborted() {
+				return
+			}
+			if p, ok = PrincipalFrom(c); !ok {
+				gin.AppErrorResponse(c, ErrUnauthorized)
+				return
+			}
+		}
+
+		span, ctx := tracing.StartSpan(c.Request.Context(), "RequirePermission", "custom")
+		allowed, err := gin.Options.RBAC.Can(ctx, p.Roles, perms...)
+		span.End()
+		if err != nil {
+			gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+			return
+		}
+		if !allowed {
+			gin.AppErrorResponse(c, ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,29 @@
+package gin
+
+import (
+	"context"
+
+	g "github.com/gin-gonic/gin"
+	ca "github.com/tossaro/go-api-core/auth"
+)
+
+// PrincipalFrom returns the caller authenticated by the auth middlewares,
+// see auth.PrincipalFrom for code without a gin context.
+func PrincipalFrom(c *g.Context) (*ca.Principal, bool) {
+	return ca.PrincipalFrom(c.Request.Context())
+}
+
+// setPrincipal stores p once for every auth type. The user_id,
+// user_role_id and user_key keys are kept for existing handlers, new code
+// should read the principal. key keeps the user_key type of each auth
+// type, *string for AuthTypeJwt and string for AuthTypeGrpc.
+func setPrincipal(c *g.Context, typ string, p *ca.Principal, key interface{}) {
+	p.TokenType = typ
+	ctx := ca.WithPrincipal(c.Request.Context(), p)
+	ctx = context.WithValue(ctx, CKey("user_id"), p.UID)
+	ctx = context.WithValue(ctx, CKey("user_role_id"), p.RoleID())
+	if typ == ca.TokenRefresh && p.SessionID != "" {
+		ctx = context.WithValue(ctx, CKey("user_key"), key)
+	}
+	c.Request = c.Request.WithContext(ctx)
+}
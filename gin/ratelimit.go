@@ -0,0 +1,321 @@
+package gin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	RateLimitFixedWindow   = "fixed_window"
+	RateLimitSlidingWindow = "sliding_window"
+	RateLimitTokenBucket   = "token_bucket"
+
+	RateLimitKeyIP         = "ip"
+	RateLimitKeyRequestKey = "request_key"
+	RateLimitKeyUser       = "user"
+
+	_defaultRateLimitPrefix = "ratelimit"
+	_memoryLimiterSweep     = time.Minute
+)
+
+// tokenBucketScript refills the bucket from the elapsed time and takes one
+// token atomically, returning {allowed, tokens left}. now is in
+// microseconds and rate in tokens per second, so requests closer than a
+// millisecond still refill.
+const tokenBucketScript = `
+local burst = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local v = redis.call('HMGET', KEYS[1], 't', 'ts')
+local tokens = tonumber(v[1]) or burst
+local ts = tonumber(v[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000000)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HMSET', KEYS[1], 't', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, tostring(tokens)}
+`
+
+var ErrTooManyRequests = NewAppError(http.StatusTooManyRequests, "too_many_requests", "too_many_requests", "Too many requests, please try again later.")
+
+type (
+	RateLimitOptions struct {
+		// Algorithm is RateLimitFixedWindow (default), RateLimitSlidingWindow
+		// or RateLimitTokenBucket.
+		Algorithm string
+		// Limit requests per Window, for the token bucket Limit tokens are
+		// refilled evenly over Window.
+		Limit  int
+		Window time.Duration
+		// Burst is the token bucket capacity, default to Limit.
+		Burst *int
+		// KeyBy is RateLimitKeyIP (default), RateLimitKeyRequestKey or
+		// RateLimitKeyUser, ignored when KeyFunc is provided.
+		KeyBy   string
+		KeyFunc func(c *g.Context) string
+		Prefix  *string
+	}
+
+	rateResult struct {
+		allowed    bool
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}
+
+	rateLimiter struct {
+		o      *RateLimitOptions
+		burst  int
+		prefix string
+		cache  cr.Cacher
+		memory *memoryLimiter
+	}
+
+	memoryLimiter struct {
+		mu      sync.Mutex
+		entries map[string]*memoryEntry
+		sweep   time.Time
+	}
+
+	memoryEntry struct {
+		window int64
+		count  int
+		prev   int
+		tokens float64
+		ts     time.Time
+	}
+)
+
+// RateLimitMiddleware limits requests with gin.Options.Redis shared by all
+// replicas, falling back to a per instance memory limiter when Redis is not
+// configured or unreachable. Use it after AuthAccessMiddleware when keyed
+// by RateLimitKeyUser.
+func (gin *Gin) RateLimitMiddleware(o *RateLimitOptions) g.HandlerFunc {
+	if o.Limit <= 0 || o.Window <= 0 {
+		gin.Options.Log.Fatal("gin - RateLimitMiddleware require Limit and Window option")
+	}
+	if o.Algorithm == "" {
+		o.Algorithm = RateLimitFixedWindow
+	}
+	l := &rateLimiter{
+		o:      o,
+		burst:  o.Limit,
+		prefix: _defaultRateLimitPrefix,
+		cache:  gin.Options.Redis,
+		memory: &memoryLimiter{entries: make(map[string]*memoryEntry)},
+	}
+	if o.Burst != nil {
+		l.burst = *(o.Burst)
+	}
+	if o.Prefix != nil {
+		l.prefix = *(o.Prefix)
+	}
+
+	return func(c *g.Context) {
+		span, ctx := tracing.StartSpan(c.Request.Context(), "RateLimitMiddleware", "custom")
+		key := rateLimitKey(c, o)
+		now := time.Now()
+
+		var res rateResult
+		var err error
+		if l.cache != nil {
+			res, err = l.allowRedis(ctx, key, now)
+			if err != nil {
+				gin.Options.Log.Error("ratelimit", err)
+			}
+		}
+		if l.cache == nil || err != nil {
+			res = l.memory.allow(l, key, now)
+		}
+		span.End()
+
+		limit := o.Limit
+		if o.Algorithm == RateLimitTokenBucket {
+			limit = l.burst
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.reset)))
+		if !res.allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(res.retryAfter)))
+			gin.AppErrorResponse(c, ErrTooManyRequests)
+			return
+		}
+		c.Next()
+	}
+}
+
+func rateLimitKey(c *g.Context, o *RateLimitOptions) string {
+	if o.KeyFunc != nil {
+		return o.KeyFunc(c)
+	}
+	switch o.KeyBy {
+	case RateLimitKeyRequestKey:
+		if k := c.GetHeader("x-request-key"); k != "" {
+			return "key:" + k
+		}
+	case RateLimitKeyUser:
+		if p, ok := PrincipalFrom(c); ok {
+			return fmt.Sprintf("user:%d", p.UID)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+func (l *rateLimiter) allowRedis(ctx context.Context, key string, now time.Time) (rateResult, error) {
+	cache := l.cache.WithContext(ctx)
+	w := l.o.Window
+	switch l.o.Algorithm {
+	case RateLimitTokenBucket:
+		rate := float64(l.o.Limit) / w.Seconds()
+		ttl := time.Duration(float64(l.burst) / rate * float64(time.Second))
+		v, err := cache.Eval(tokenBucketScript, []string{l.prefix + ":" + key}, l.burst, rate, now.UnixMicro(), (ttl + time.Second).Milliseconds())
+		if err != nil {
+			return rateResult{}, err
+		}
+		r, ok := v.([]interface{})
+		if !ok || len(r) != 2 {
+			return rateResult{}, errors.New("ratelimit - unexpected token bucket reply")
+		}
+		allowed, _ := r[0].(int64)
+		tokens, _ := strconv.ParseFloat(fmt.Sprint(r[1]), 64)
+		return tokenResult(allowed == 1, tokens, l.burst, rate), nil
+
+	case RateLimitSlidingWindow:
+		win := now.UnixNano() / int64(w)
+		cur, err := cache.Incr(l.prefix, key+":"+strconv.FormatInt(win, 10), 2*w)
+		if err != nil {
+			return rateResult{}, err
+		}
+		prev := 0
+		pv, err := cache.Get(l.prefix, key+":"+strconv.FormatInt(win-1, 10))
+		if err != nil && !errors.Is(err, cr.Nil) {
+			return rateResult{}, err
+		}
+		if pv != "" {
+			prev, _ = strconv.Atoi(pv)
+		}
+		return slidingResult(now, w, int(cur), prev, l.o.Limit), nil
+
+	default:
+		win := now.UnixNano() / int64(w)
+		n, err := cache.Incr(l.prefix, key+":"+strconv.FormatInt(win, 10), w)
+		if err != nil {
+			return rateResult{}, err
+		}
+		return fixedResult(now, w, int(n), l.o.Limit), nil
+	}
+}
+
+func fixedResult(now time.Time, w time.Duration, n int, limit int) rateResult {
+	reset := w - time.Duration(now.UnixNano()%int64(w))
+	return rateResult{
+		allowed:    n <= limit,
+		remaining:  max(0, limit-n),
+		reset:      reset,
+		retryAfter: reset,
+	}
+}
+
+// slidingResult weights the previous window by the part of it still
+// covered by the sliding window.
+func slidingResult(now time.Time, w time.Duration, cur int, prev int, limit int) rateResult {
+	elapsed := time.Duration(now.UnixNano() % int64(w))
+	weight := float64(w-elapsed) / float64(w)
+	est := int(math.Floor(float64(prev)*weight)) + cur
+	reset := w - elapsed
+	return rateResult{
+		allowed:    est <= limit,
+		remaining:  max(0, limit-est),
+		reset:      reset,
+		retryAfter: reset,
+	}
+}
+
+// tokenResult reports a token bucket refilled with rate tokens per second.
+func tokenResult(allowed bool, tokens float64, burst int, rate float64) rateResult {
+	perToken := time.Duration(float64(time.Second) / rate)
+	res := rateResult{
+		allowed:   allowed,
+		remaining: int(math.Floor(tokens)),
+		reset:     time.Duration((float64(burst) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		res.retryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+		if res.retryAfter <= 0 {
+			res.retryAfter = perToken
+		}
+	}
+	return res
+}
+
+func (m *memoryLimiter) allow(l *rateLimiter, key string, now time.Time) rateResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := l.o.Window
+	if now.Sub(m.sweep) > _memoryLimiterSweep {
+		for k, e := range m.entries {
+			if now.Sub(e.ts) > 2*w && now.Sub(e.ts) > _memoryLimiterSweep {
+				delete(m.entries, k)
+			}
+		}
+		m.sweep = now
+	}
+
+	e, ok := m.entries[key]
+	if !ok {
+		e = &memoryEntry{tokens: float64(l.burst), ts: now}
+		m.entries[key] = e
+	}
+
+	switch l.o.Algorithm {
+	case RateLimitTokenBucket:
+		rate := float64(l.o.Limit) / w.Seconds()
+		e.tokens = math.Min(float64(l.burst), e.tokens+now.Sub(e.ts).Seconds()*rate)
+		e.ts = now
+		allowed := e.tokens >= 1
+		if allowed {
+			e.tokens--
+		}
+		return tokenResult(allowed, e.tokens, l.burst, rate)
+
+	default:
+		win := now.UnixNano() / int64(w)
+		switch {
+		case e.window == win:
+		case e.window == win-1:
+			e.prev, e.count = e.count, 0
+		default:
+			e.prev, e.count = 0, 0
+		}
+		e.window = win
+		e.count++
+		e.ts = now
+		if l.o.Algorithm == RateLimitSlidingWindow {
+			return slidingResult(now, w, e.count, e.prev, l.o.Limit)
+		}
+		return fixedResult(now, w, e.count, l.o.Limit)
+	}
+}
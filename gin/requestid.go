@@ -0,0 +1,76 @@
+package gin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	HeaderRequestID = "X-Request-ID"
+
+	_maxRequestIDLength = 128
+)
+
+// RequestIDMiddleware keeps the X-Request-ID sent by the client, or a new
+// one when missing or invalid, in the request context, echoes it on the
+// response and labels the request span with it.
+func RequestIDMiddleware() g.HandlerFunc {
+	return func(c *g.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		ctx := context.WithValue(c.Request.Context(), CKey("request_id"), id)
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(HeaderRequestID, id)
+
+		tracing.SpanFromContext(ctx).SetAttribute("request_id", id)
+		c.Next()
+	}
+}
+
+// RequestIDFrom returns the request id set by RequestIDMiddleware, empty
+// when there is none.
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(CKey("request_id")).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > _maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	var s [36]byte
+	hex.Encode(s[0:8], b[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], b[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], b[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], b[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], b[10:])
+	return string(s[:])
+}
@@ -0,0 +1,161 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	HeaderRequestKey       = "x-request-key"
+	HeaderRequestTimestamp = "x-request-timestamp"
+	HeaderRequestNonce     = "x-request-nonce"
+	HeaderRequestSignature = "x-request-signature"
+
+	_defaultSignatureSkew = 5 * time.Minute
+	// _defaultSignatureBodyLimit caps the body read to sign it without
+	// BodyLimitMiddleware.
+	_defaultSignatureBodyLimit = 10 << 20
+	_signatureNoncePrefix      = "nonce"
+)
+
+var (
+	ErrInvalidSignature = NewAppError(http.StatusUnauthorized, "invalid_signature", "invalid_signature", "Invalid request signature.")
+	ErrSignatureExpired = NewAppError(http.StatusUnauthorized, "signature_expired", "signature_expired", "Request signature has expired.")
+	ErrReplayedRequest  = NewAppError(http.StatusUnauthorized, "replayed_request", "replayed_request", "Request has already been processed.")
+
+	ErrUnknownClient = errors.New("unknown client key")
+)
+
+type (
+	// ClientRegistry resolves the secret of a client key sent in the
+	// x-request-key header, ErrUnknownClient is expected for unknown keys.
+	ClientRegistry interface {
+		Secret(ctx context.Context, key string) (string, error)
+	}
+
+	// StaticClients is a ClientRegistry of key to secret, e.g. loaded
+	// from the environment.
+	StaticClients map[string]string
+)
+
+func (s StaticClients) Secret(_ context.Context, key string) (string, error) {
+	secret, ok := s[key]
+	if !ok {
+		return "", ErrUnknownClient
+	}
+	return secret, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of the canonical request:
+// METHOD \n REQUEST_URI \n TIMESTAMP \n NONCE \n hex(SHA256(BODY))
+func Sign(secret string, method string, uri string, timestamp string, nonce string, body []byte) string {
+	bh := sha256.Sum256(body)
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n" + nonce + "\n" + hex.EncodeToString(bh[:])))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// SignRequest sets the signature headers on a client request.
+func SignRequest(r *http.Request, key string, secret string, nonce string, body []byte) {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	r.Header.Set(HeaderRequestKey, key)
+	r.Header.Set(HeaderRequestTimestamp, ts)
+	r.Header.Set(HeaderRequestNonce, nonce)
+	r.Header.Set(HeaderRequestSignature, Sign(secret, r.Method, r.URL.RequestURI(), ts, nonce, body))
+}
+
+// SignatureMiddleware verifies the request signature against
+// gin.Options.Clients, nonces are kept in gin.Options.Redis for twice the
+// allowed clock skew to reject replays.
+func (gin *Gin) SignatureMiddleware() g.HandlerFunc {
+	if gin.Options.Clients == nil {
+		gin.Options.Log.Fatal("gin - SignatureMiddleware require Clients option")
+	}
+	if gin.Options.Redis == nil {
+		gin.Options.Log.Fatal("gin - SignatureMiddleware require Redis option")
+	}
+	return func(c *g.Context) {
+		if !gin.verifySignature(c) {
+			return
+		}
+		c.Next()
+	}
+}
+
+func (gin *Gin) verifySignature(c *g.Context) bool {
+	span, ctx := tracing.StartSpan(c.Request.Context(), "verifySignature", "custom")
+	defer span.End()
+
+	key := c.GetHeader(HeaderRequestKey)
+	ts := c.GetHeader(HeaderRequestTimestamp)
+	nonce := c.GetHeader(HeaderRequestNonce)
+	sig := c.GetHeader(HeaderRequestSignature)
+	if key == "" || ts == "" || nonce == "" || sig == "" {
+		gin.AppErrorResponse(c, ErrInvalidSignature.Wrap(errors.New("missing signature header")))
+		return false
+	}
+
+	skew := _defaultSignatureSkew
+	if gin.Options.SignatureSkew != nil {
+		skew = *(gin.Options.SignatureSkew)
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		gin.AppErrorResponse(c, ErrInvalidSignature.Wrap(err))
+		return false
+	}
+	if d := time.Since(time.Unix(sec, 0)); d > skew || d < -skew {
+		gin.AppErrorResponse(c, ErrSignatureExpired)
+		return false
+	}
+
+	secret, err := gin.Options.Clients.Secret(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrUnknownClient) {
+			gin.Options.Log.Error("signature", err)
+		}
+		gin.AppErrorResponse(c, ErrInvalidSignature.Wrap(err))
+		return false
+	}
+
+	var body []byte
+	if c.Request.Body != nil {
+		body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, requestBodyLimit(c, _defaultSignatureBodyLimit)))
+		if err != nil {
+			gin.AppErrorResponse(c, bodyError(err))
+			return false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	exp := Sign(secret, c.Request.Method, c.Request.URL.RequestURI(), ts, nonce, body)
+	if !hmac.Equal([]byte(exp), []byte(sig)) {
+		gin.AppErrorResponse(c, ErrInvalidSignature)
+		return false
+	}
+
+	ok, err := gin.Options.Redis.WithContext(c.Request.Context()).SetNX(_signatureNoncePrefix, key+":"+nonce, ts, 2*skew)
+	if err != nil {
+		gin.Options.Log.Error("signature", err)
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return false
+	}
+	if !ok {
+		gin.AppErrorResponse(c, ErrReplayedRequest)
+		return false
+	}
+
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), CKey("client_key"), key))
+	return true
+}
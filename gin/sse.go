@@ -0,0 +1,273 @@
+package gin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderLastEventID = "Last-Event-ID"
+
+	_defaultSSEHeartbeat    = 15 * time.Second
+	_defaultSSEWriteTimeout = 10 * time.Second
+	_defaultReplaySize      = 100
+	_defaultReplayTTL       = time.Hour
+	_eventStreamPrefix      = "sse"
+)
+
+// appendEventScript numbers the event, keeps the last ARGV[2] of them and
+// refreshes the expiry of the stream.
+const appendEventScript = `
+local id = redis.call('INCR', KEYS[2])
+redis.call('ZADD', KEYS[1], id, id .. ':' .. ARGV[1])
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -tonumber(ARGV[2]) - 1)
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+return id
+`
+
+const eventsSinceScript = `
+return redis.call('ZRANGEBYSCORE', KEYS[1], '(' .. ARGV[1], '+inf')
+`
+
+type (
+	// Event is a server-sent event. Data is sent as is when it is a string
+	// or []byte, as json otherwise.
+	Event struct {
+		ID    string
+		Event string
+		Data  interface{}
+	}
+
+	SSEOptions struct {
+		// Heartbeat comment interval keeping proxies from closing an idle
+		// stream, default 15s.
+		Heartbeat *time.Duration
+		// WriteTimeout of every write, pushed forward on each one so the
+		// stream outlives the http server WriteTimeout. Default 10s.
+		WriteTimeout *time.Duration
+		// Retry tells the client how long to wait before reconnecting.
+		Retry *time.Duration
+		// Replay sends the events a reconnecting client missed after its
+		// Last-Event-ID.
+		Replay *EventStream
+	}
+
+	EventStreamOptions struct {
+		// Size of the replay buffer, default 100 events.
+		Size *int64
+		// TTL of the buffer after the last event, default 1h.
+		TTL *time.Duration
+	}
+
+	// EventStream is a replay buffer of events kept in Redis, shared by
+	// every replica. Its ids are increasing numbers.
+	EventStream struct {
+		gin  *Gin
+		key  string
+		size int64
+		ttl  time.Duration
+	}
+
+	storedEvent struct {
+		Event string `json:"e,omitempty"`
+		Data  string `json:"d"`
+	}
+)
+
+// EventStream returns the replay buffer of stream name, requires
+// Options.Redis. Keys are scoped to the tenant of the context given to
+// Append and Since.
+func (gin *Gin) EventStream(name string, o *EventStreamOptions) *EventStream {
+	if gin.Options.Redis == nil {
+		gin.Options.Log.Fatal("gin - EventStream require Redis option")
+	}
+	s := &EventStream{gin: gin, key: _eventStreamPrefix + ":{" + name + "}", size: _defaultReplaySize, ttl: _defaultReplayTTL}
+	if o.Size != nil {
+		s.size = *(o.Size)
+	}
+	if o.TTL != nil {
+		s.ttl = *(o.TTL)
+	}
+	return s
+}
+
+// Append stores e and returns it with its id, to be sent to the live
+// streams.
+func (s *EventStream) Append(ctx context.Context, e Event) (Event, error) {
+	data, err := eventData(e.Data)
+	if err != nil {
+		return e, err
+	}
+	b, err := json.Marshal(storedEvent{Event: e.Event, Data: data})
+	if err != nil {
+		return e, err
+	}
+	v, err := s.gin.Options.Redis.WithContext(ctx).Eval(appendEventScript, []string{s.key, s.key + ":seq"}, b, s.size, s.ttl.Milliseconds())
+	if err != nil {
+		return e, err
+	}
+	id, ok := v.(int64)
+	if !ok {
+		return e, fmt.Errorf("sse - unexpected event id %v", v)
+	}
+	e.ID, e.Data = strconv.FormatInt(id, 10), data
+	return e, nil
+}
+
+// Since returns the buffered events after id, oldest first.
+func (s *EventStream) Since(ctx context.Context, id string) ([]Event, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, nil
+	}
+	v, err := s.gin.Options.Redis.WithContext(ctx).Eval(eventsSinceScript, []string{s.key}, id)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := v.([]interface{})
+	events := make([]Event, 0, len(items))
+	for _, it := range items {
+		m, _ := it.(string)
+		id, raw, ok := strings.Cut(m, ":")
+		var se storedEvent
+		if !ok || json.Unmarshal([]byte(raw), &se) != nil {
+			continue
+		}
+		events = append(events, Event{ID: id, Event: se.Event, Data: se.Data})
+	}
+	return events, nil
+}
+
+// SSE streams events to the client until events is closed, the client
+// goes away or the server shuts down. With o.Replay the events after
+// Last-Event-ID are sent first, and live events already replayed are
+// skipped.
+func (gin *Gin) SSE(c *g.Context, o *SSEOptions, events <-chan Event) {
+	heartbeat := _defaultSSEHeartbeat
+	if o.Heartbeat != nil {
+		heartbeat = *(o.Heartbeat)
+	}
+	timeout := _defaultSSEWriteTimeout
+	if o.WriteTimeout != nil {
+		timeout = *(o.WriteTimeout)
+	}
+	rc := http.NewResponseController(c.Writer)
+	write := func(s string) bool {
+		// ErrNotSupported means a c.Writer wrapper lacks Unwrap, the
+		// server WriteTimeout would cut the stream
+		if err := rc.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+			gin.Options.Log.Error("sse", err)
+			return false
+		}
+		if _, err := c.Writer.WriteString(s); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	open := ":\n\n"
+	if o.Retry != nil {
+		open = "retry: " + strconv.FormatInt(o.Retry.Milliseconds(), 10) + "\n\n"
+	}
+	if !write(open) {
+		return
+	}
+
+	var last int64
+	if o.Replay != nil {
+		if id := c.GetHeader(HeaderLastEventID); id != "" {
+			missed, err := o.Replay.Since(c.Request.Context(), id)
+			if err != nil {
+				gin.Options.Log.Error("sse", err)
+			}
+			last, _ = strconv.ParseInt(id, 10, 64)
+			for _, e := range missed {
+				if !gin.writeEvent(write, e) {
+					return
+				}
+				last, _ = strconv.ParseInt(e.ID, 10, 64)
+			}
+		}
+	}
+
+	t := time.NewTicker(heartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-gin.Done():
+			return
+		case <-t.C:
+			if !write(": ping\n\n") {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if n, err := strconv.ParseInt(e.ID, 10, 64); err == nil && o.Replay != nil && n <= last {
+				continue
+			}
+			if !gin.writeEvent(write, e) {
+				return
+			}
+		}
+	}
+}
+
+// writeEvent skips events whose data does not encode, it reports whether
+// the stream is still open.
+func (gin *Gin) writeEvent(write func(s string) bool, e Event) bool {
+	s, err := formatEvent(e)
+	if err != nil {
+		gin.Options.Log.Error("sse", err)
+		return true
+	}
+	return write(s)
+}
+
+func eventData(v interface{}) (string, error) {
+	switch d := v.(type) {
+	case string:
+		return d, nil
+	case []byte:
+		return string(d), nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func formatEvent(e Event) (string, error) {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		b.WriteString("event: " + e.Event + "\n")
+	}
+	data, err := eventData(e.Data)
+	if err != nil {
+		return "", err
+	}
+	for _, l := range strings.Split(data, "\n") {
+		b.WriteString("data: " + l + "\n")
+	}
+	b.WriteString("\n")
+	return b.String(), nil
+}
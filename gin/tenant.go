@@ -0,0 +1,127 @@
+package gin
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/tenant"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	TenantFromClaim     = "claim"
+	TenantFromHeader    = "header"
+	TenantFromSubdomain = "subdomain"
+
+	HeaderTenantID = "x-tenant-id"
+)
+
+var (
+	ErrTenantRequired = NewAppError(http.StatusBadRequest, "tenant_required", "tenant_required", "Tenant is required.")
+	ErrTenantNotFound = NewAppError(http.StatusNotFound, "tenant_not_found", "tenant_not_found", "Tenant not found.")
+)
+
+type TenantOptions struct {
+	// Sources are tried in order, default TenantFromClaim, TenantFromHeader
+	// then TenantFromSubdomain.
+	Sources []string
+	// Header carrying the tenant, default x-tenant-id.
+	Header *string
+	// Domain is the base domain of TenantFromSubdomain, "acme.example.com"
+	// resolves to acme with Domain example.com. The source is skipped
+	// without it.
+	Domain *string
+	// Optional lets requests without tenant through unscoped.
+	Optional bool
+	// Exists reports whether the tenant is known, unknown ones get 404.
+	Exists func(c *g.Context, id string) (bool, error)
+}
+
+// TenantMiddleware resolves the tenant of the request and scopes its
+// context, see tenant.From, so Redis keys and Postgres WithTenant are
+// isolated. Put it after AuthAccessMiddleware for TenantFromClaim, a
+// token bound to a tenant is then refused on any other one.
+func (gin *Gin) TenantMiddleware(o *TenantOptions) g.HandlerFunc {
+	sources := o.Sources
+	if len(sources) == 0 {
+		sources = []string{TenantFromClaim, TenantFromHeader, TenantFromSubdomain}
+	}
+	header := HeaderTenantID
+	if o.Header != nil {
+		header = *(o.Header)
+	}
+
+	return func(c *g.Context) {
+		p, _ := PrincipalFrom(c)
+		var id string
+		for _, s := range sources {
+			if id = tenantFrom(c, s, header, o.Domain); id != "" {
+				break
+			}
+		}
+
+		if id == "" {
+			if o.Optional {
+				c.Next()
+				return
+			}
+			gin.AppErrorResponse(c, ErrTenantRequired)
+			return
+		}
+		if !tenant.Valid(id) {
+			gin.AppErrorResponse(c, ErrTenantNotFound)
+			return
+		}
+		if p != nil && p.Tenant != "" && p.Tenant != id {
+			gin.AppErrorResponse(c, ErrForbidden)
+			return
+		}
+		if o.Exists != nil {
+			ok, err := o.Exists(c, id)
+			if err != nil {
+				gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+				return
+			}
+			if !ok {
+				gin.AppErrorResponse(c, ErrTenantNotFound)
+				return
+			}
+		}
+
+		if p != nil {
+			p.Tenant = id
+		}
+		ctx := tenant.With(c.Request.Context(), id)
+		tracing.SpanFromContext(ctx).SetAttribute("tenant", id)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func tenantFrom(c *g.Context, source string, header string, domain *string) string {
+	switch source {
+	case TenantFromClaim:
+		if p, ok := PrincipalFrom(c); ok {
+			return p.Tenant
+		}
+	case TenantFromHeader:
+		return strings.ToLower(strings.TrimSpace(c.GetHeader(header)))
+	case TenantFromSubdomain:
+		if domain == nil {
+			return ""
+		}
+		host := c.Request.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+		sub := strings.TrimSuffix(host, "."+strings.ToLower(*domain))
+		if sub == host || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+	return ""
+}
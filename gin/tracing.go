@@ -0,0 +1,31 @@
+package gin
+
+import (
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+// TracingMiddleware starts the root span of every request with the default
+// tracer, continuing the trace of a W3C traceparent header.
+func TracingMiddleware() g.HandlerFunc {
+	return func(c *g.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = _unmatchedRoute
+		}
+		span, ctx := tracing.Default().StartRequest(c.Request.Context(), c.Request.Method+" "+route, tracing.HeaderCarrier(c.Request.Header))
+		defer span.End()
+		span.SetAttribute("http.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("http.target", c.Request.URL.RequestURI())
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.status_code", status)
+		if status >= 500 && len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last().Err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package gin
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/tossaro/go-api-core/storage"
+)
+
+const (
+	_defaultUploadMaxSize  = 10 << 20
+	_defaultUploadMaxFiles = 10
+	_defaultUploadPrefix   = "uploads/"
+	_maxFormValue          = 1 << 20
+	_sniffLength           = 512
+)
+
+var (
+	ErrFileRequired = NewAppError(http.StatusBadRequest, "file_required", "file_required", "A file is required.")
+	ErrTooManyFiles = NewAppError(http.StatusBadRequest, "too_many_files", "too_many_files", "Too many files, the limit is {{.Limit}}.")
+	ErrFileTooLarge = NewAppError(http.StatusRequestEntityTooLarge, "file_too_large", "file_too_large", "File {{.Filename}} is too large, the limit is {{.Limit}} bytes.")
+	ErrFileType     = NewAppError(http.StatusUnsupportedMediaType, "unsupported_file_type", "unsupported_file_type", "File {{.Filename}} has the unsupported type {{.Type}}.")
+	ErrFileInfected = NewAppError(http.StatusUnprocessableEntity, "file_infected", "file_infected", "File {{.Filename}} was rejected by the virus scan.")
+)
+
+type (
+	// Scanner checks an upload for malware while it is stored, r is the
+	// file content. An error rejects the upload as a scan failure.
+	Scanner func(ctx context.Context, filename string, r io.Reader) (clean bool, err error)
+
+	UploadOptions struct {
+		Storage storage.Storage
+		// Fields accepting files, any field when empty.
+		Fields []string
+		// Required rejects a request without file with ErrFileRequired.
+		Required bool
+		// MaxSize of every file in bytes, default 10MB.
+		MaxSize *int64
+		// MaxFiles per request, default 10.
+		MaxFiles *int
+		// Types allowed, sniffed from the content and not trusted from the
+		// client, such as "application/pdf" or "image/*". Any when empty.
+		Types []string
+		// Key of the stored file, default "uploads/<random><ext>".
+		Key func(c *g.Context, f *UploadedFile) string
+		// Scan runs alongside the upload, infected files are deleted and
+		// rejected with ErrFileInfected.
+		Scan Scanner
+	}
+
+	UploadedFile struct {
+		Field       string `json:"field"`
+		Filename    string `json:"filename"`
+		Key         string `json:"key"`
+		ContentType string `json:"content_type"`
+		Size        int64  `json:"size"`
+	}
+
+	Upload struct {
+		Files []UploadedFile `json:"files"`
+		// Values of the form fields which are not files.
+		Values map[string][]string `json:"values"`
+	}
+
+	// limitedFile counts the bytes read and fails past max.
+	limitedFile struct {
+		r   io.Reader
+		n   int64
+		max int64
+	}
+
+	scanResult struct {
+		clean bool
+		err   error
+	}
+)
+
+var errFileTooLarge = errors.New("file too large")
+
+func (l *limitedFile) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.max {
+		return n, errFileTooLarge
+	}
+	return n, err
+}
+
+// Upload streams the files of a multipart request to o.Storage without
+// buffering them on disk or in memory. Files are validated while read,
+// on failure the ones already stored are deleted and an *AppError
+// returned.
+func (gin *Gin) Upload(c *g.Context, o *UploadOptions) (*Upload, error) {
+	if o.Storage == nil {
+		gin.Options.Log.Fatal("gin - Upload require Storage option")
+	}
+	maxSize := int64(_defaultUploadMaxSize)
+	if o.MaxSize != nil {
+		maxSize = *(o.MaxSize)
+	}
+	maxFiles := _defaultUploadMaxFiles
+	if o.MaxFiles != nil {
+		maxFiles = *(o.MaxFiles)
+	}
+
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		return nil, ErrMalformedBody.Wrap(err)
+	}
+	u := &Upload{Values: make(map[string][]string)}
+	fail := func(err error) (*Upload, error) {
+		for _, f := range u.Files {
+			if derr := o.Storage.Delete(c.Request.Context(), f.Key); derr != nil {
+				gin.Options.Log.Error("upload", derr)
+			}
+		}
+		return nil, err
+	}
+
+	for {
+		p, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fail(bodyError(err))
+		}
+		name := p.FormName()
+		if p.FileName() == "" {
+			v, err := io.ReadAll(io.LimitReader(p, _maxFormValue+1))
+			if err != nil {
+				return fail(bodyError(err))
+			}
+			if len(v) > _maxFormValue {
+				return fail(ErrPayloadTooLarge.WithData(map[string]interface{}{"Limit": _maxFormValue}))
+			}
+			u.Values[name] = append(u.Values[name], string(v))
+			continue
+		}
+		if len(o.Fields) > 0 && !containsString(o.Fields, name) {
+			continue
+		}
+		if len(u.Files) >= maxFiles {
+			return fail(ErrTooManyFiles.WithData(map[string]interface{}{"Limit": maxFiles}))
+		}
+
+		f, err := gin.uploadFile(c, o, p, maxSize)
+		if f != nil {
+			u.Files = append(u.Files, *f)
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	if o.Required && len(u.Files) == 0 {
+		return nil, ErrFileRequired
+	}
+	return u, nil
+}
+
+// uploadFile stores one part, the file is returned with the error when
+// it was stored before being rejected so it gets deleted.
+func (gin *Gin) uploadFile(c *g.Context, o *UploadOptions, p *multipart.Part, maxSize int64) (*UploadedFile, error) {
+	f := &UploadedFile{Field: p.FormName(), Filename: path.Base(p.FileName())}
+	data := map[string]interface{}{"Filename": f.Filename, "Limit": maxSize}
+
+	br := bufio.NewReaderSize(p, _sniffLength)
+	head, err := br.Peek(_sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, bodyError(err)
+	}
+	f.ContentType = http.DetectContentType(head)
+	if len(o.Types) > 0 && !allowedType(o.Types, f.ContentType) {
+		data["Type"] = strings.SplitN(f.ContentType, ";", 2)[0]
+		return nil, ErrFileType.WithData(data)
+	}
+	if o.Key != nil {
+		f.Key = o.Key(c, f)
+	} else {
+		f.Key = uploadKey(f.Filename)
+	}
+
+	ctx := c.Request.Context()
+	lf := &limitedFile{r: br, max: maxSize}
+	body := io.Reader(lf)
+	var (
+		pw      *io.PipeWriter
+		scanned chan scanResult
+	)
+	if o.Scan != nil {
+		var pr *io.PipeReader
+		pr, pw = io.Pipe()
+		scanned = make(chan scanResult, 1)
+		go func() {
+			clean, err := o.Scan(ctx, f.Filename, pr)
+			// the scanner may stop reading early
+			_, _ = io.Copy(io.Discard, pr)
+			scanned <- scanResult{clean: clean, err: err}
+		}()
+		body = io.TeeReader(lf, pw)
+	}
+
+	// the part Content-Length is not trusted as Size, an understated one
+	// would get the object stored truncated
+	obj, err := o.Storage.Put(ctx, f.Key, body, &storage.PutOptions{ContentType: f.ContentType, MaxSize: maxSize})
+	if pw != nil {
+		pw.CloseWithError(err)
+	}
+	if err != nil {
+		if pw != nil {
+			<-scanned
+		}
+		if lf.n > lf.max {
+			return nil, ErrFileTooLarge.WithData(data)
+		}
+		return nil, bodyError(err)
+	}
+	f.Size = obj.Size
+
+	if pw != nil {
+		res := <-scanned
+		if res.err != nil {
+			return f, ErrInternal.Wrap(res.err)
+		}
+		if !res.clean {
+			return f, ErrFileInfected.WithData(data)
+		}
+	}
+	return f, nil
+}
+
+// Download redirects to a presigned url of key valid for expiry, or
+// streams the object when s cannot presign.
+func (gin *Gin) Download(c *g.Context, s storage.Storage, key string, expiry time.Duration) {
+	ctx := c.Request.Context()
+	if _, err := s.Stat(ctx, key); err != nil {
+		gin.AppErrorResponse(c, storageError(err))
+		return
+	}
+	u, err := s.PresignGet(ctx, key, expiry)
+	if err == nil {
+		c.Redirect(http.StatusTemporaryRedirect, u)
+		return
+	}
+	if !errors.Is(err, storage.ErrPresignUnsupported) {
+		gin.AppErrorResponse(c, ErrInternal.Wrap(err))
+		return
+	}
+	r, obj, err := s.Get(ctx, key)
+	if err != nil {
+		gin.AppErrorResponse(c, storageError(err))
+		return
+	}
+	defer r.Close()
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, r, nil)
+}
+
+func storageError(err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrNotFound.Wrap(err)
+	}
+	return ErrInternal.Wrap(err)
+}
+
+func allowedType(types []string, ct string) bool {
+	ct = strings.TrimSpace(strings.SplitN(ct, ";", 2)[0])
+	for _, t := range types {
+		if t == ct || (strings.HasSuffix(t, "/*") && strings.HasPrefix(ct, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadKey keeps a short alphanumeric extension of the client file name
+// only, the rest is random.
+func uploadKey(filename string) string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	ext := strings.ToLower(path.Ext(filename))
+	if len(ext) > 10 {
+		ext = ""
+	}
+	for _, r := range strings.TrimPrefix(ext, ".") {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			ext = ""
+			break
+		}
+	}
+	return _defaultUploadPrefix + hex.EncodeToString(b[:]) + ext
+}
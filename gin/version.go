@@ -0,0 +1,236 @@
+package gin
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+)
+
+const (
+	VersionByPath   = "path"
+	VersionByHeader = "header"
+
+	HeaderAPIVersion  = "x-api-version"
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+
+	_versionIndexKey = "_gin_version_index"
+)
+
+var (
+	ErrUnsupportedVersion = NewAppError(http.StatusBadRequest, "unsupported_version", "unsupported_version", "API version {{.Version}} is not supported.")
+	ErrVersionRetired     = NewAppError(http.StatusGone, "version_retired", "version_retired", "API version {{.Version}} has been retired.")
+)
+
+type (
+	APIVersion struct {
+		Name string
+		// Deprecated announces the deprecation date with the Deprecation
+		// header, it may be in the future.
+		Deprecated *time.Time
+		// Sunset announces the retirement with the Sunset header, the
+		// version answers 410 once it is past.
+		Sunset *time.Time
+		// Link documents the deprecation, sent as Link rel="deprecation".
+		Link *string
+	}
+
+	VersioningOptions struct {
+		// By is VersionByPath (default), routes under /v1 and /v2, or
+		// VersionByHeader, one route reading x-api-version or the Accept
+		// media type "application/vnd.<vendor>.v2+json".
+		By string
+		// Versions oldest first.
+		Versions []APIVersion
+		// Default version of header requests naming none, default the
+		// latest one.
+		Default *string
+		// Vendor of the media type, default BaseUrl.
+		Vendor *string
+	}
+
+	// VersionHandlers maps a version name to its handler. A version without
+	// entry inherits the handler of the previous one, a nil handler removes
+	// the route from that version on.
+	VersionHandlers map[string]g.HandlerFunc
+
+	Versioning struct {
+		gin    *Gin
+		r      *g.RouterGroup
+		o      *VersioningOptions
+		index  map[string]int
+		def    int
+		vendor string
+	}
+)
+
+// Versioned serves the versions of o on r, modules then register the
+// handlers of every version side by side:
+//
+//	vs.GET("/orders", gin.VersionHandlers{"v1": m.listV1, "v2": m.listV2})
+func (gin *Gin) Versioned(r *g.RouterGroup, o *VersioningOptions) *Versioning {
+	if len(o.Versions) == 0 {
+		gin.Options.Log.Fatal("gin - Versioned require Versions option")
+	}
+	if o.By == "" {
+		o.By = VersionByPath
+	}
+	if o.By != VersionByPath && o.By != VersionByHeader {
+		gin.Options.Log.Fatal("gin - Versioned unknown By option " + o.By)
+	}
+
+	vs := &Versioning{gin: gin, r: r, o: o, index: make(map[string]int), vendor: gin.Options.BaseUrl}
+	for i, v := range o.Versions {
+		vs.index[v.Name] = i
+	}
+	vs.def = len(o.Versions) - 1
+	if o.Default != nil {
+		i, ok := vs.index[*(o.Default)]
+		if !ok {
+			gin.Options.Log.Fatal("gin - Versioned Default option is not one of Versions")
+		}
+		vs.def = i
+	}
+	if o.Vendor != nil {
+		vs.vendor = *(o.Vendor)
+	}
+	return vs
+}
+
+// APIVersionFrom returns the version serving the request, empty outside
+// versioned routes.
+func APIVersionFrom(ctx context.Context) string {
+	v, _ := ctx.Value(CKey("api_version")).(string)
+	return v
+}
+
+// Handle registers h for every version, m runs after the version is
+// resolved and before its handler.
+func (vs *Versioning) Handle(method string, path string, h VersionHandlers, m ...g.HandlerFunc) {
+	chain := make([]g.HandlerFunc, len(vs.o.Versions))
+	for name := range h {
+		if _, ok := vs.index[name]; !ok {
+			vs.gin.Options.Log.Fatal("gin - Versioning unknown version " + name)
+		}
+	}
+	var cur g.HandlerFunc
+	for i, v := range vs.o.Versions {
+		if f, ok := h[v.Name]; ok {
+			cur = f
+		}
+		chain[i] = cur
+	}
+
+	if vs.o.By == VersionByPath {
+		for i, v := range vs.o.Versions {
+			if chain[i] == nil {
+				continue
+			}
+			i := i
+			handlers := append([]g.HandlerFunc{func(c *g.Context) { vs.serve(c, i) }}, m...)
+			vs.r.Group("/"+v.Name).Handle(method, path, append(handlers, chain[i])...)
+		}
+		return
+	}
+
+	resolve := func(c *g.Context) {
+		c.Writer.Header().Add("Vary", HeaderAPIVersion+", Accept")
+		i, ok := vs.requested(c)
+		if !ok {
+			return
+		}
+		vs.serve(c, i)
+		if c.IsAborted() {
+			return
+		}
+		if chain[i] == nil {
+			vs.gin.AppErrorResponse(c, ErrNotFound)
+			return
+		}
+		c.Set(_versionIndexKey, i)
+	}
+	dispatch := func(c *g.Context) {
+		chain[c.GetInt(_versionIndexKey)](c)
+	}
+	vs.r.Handle(method, path, append(append([]g.HandlerFunc{resolve}, m...), dispatch)...)
+}
+
+func (vs *Versioning) GET(path string, h VersionHandlers, m ...g.HandlerFunc) {
+	vs.Handle(http.MethodGet, path, h, m...)
+}
+
+func (vs *Versioning) POST(path string, h VersionHandlers, m ...g.HandlerFunc) {
+	vs.Handle(http.MethodPost, path, h, m...)
+}
+
+func (vs *Versioning) PUT(path string, h VersionHandlers, m ...g.HandlerFunc) {
+	vs.Handle(http.MethodPut, path, h, m...)
+}
+
+func (vs *Versioning) PATCH(path string, h VersionHandlers, m ...g.HandlerFunc) {
+	vs.Handle(http.MethodPatch, path, h, m...)
+}
+
+func (vs *Versioning) DELETE(path string, h VersionHandlers, m ...g.HandlerFunc) {
+	vs.Handle(http.MethodDelete, path, h, m...)
+}
+
+// requested resolves the version named by x-api-version or the Accept
+// media type, the default one when neither is sent.
+func (vs *Versioning) requested(c *g.Context) (int, bool) {
+	name := c.GetHeader(HeaderAPIVersion)
+	if name == "" {
+		prefix := "application/vnd." + strings.ToLower(vs.vendor) + "."
+		for _, a := range strings.Split(c.GetHeader("Accept"), ",") {
+			mt := strings.ToLower(strings.TrimSpace(strings.SplitN(a, ";", 2)[0]))
+			if strings.HasPrefix(mt, prefix) {
+				name = strings.SplitN(strings.TrimPrefix(mt, prefix), "+", 2)[0]
+				break
+			}
+		}
+	}
+	if name == "" {
+		return vs.def, true
+	}
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "v") {
+		name = "v" + name
+	}
+	i, ok := vs.index[name]
+	if !ok {
+		vs.gin.AppErrorResponse(c, ErrUnsupportedVersion.WithData(map[string]interface{}{"Version": name}))
+		return 0, false
+	}
+	return i, true
+}
+
+// serve announces the lifecycle of version i, counts its usage and
+// refuses it once past its sunset.
+func (vs *Versioning) serve(c *g.Context, i int) {
+	v := vs.o.Versions[i]
+	now := time.Now()
+	if v.Sunset != nil && now.After(*(v.Sunset)) {
+		vs.gin.AppErrorResponse(c, ErrVersionRetired.WithData(map[string]interface{}{"Version": v.Name}))
+		return
+	}
+
+	h := c.Writer.Header()
+	h.Set(HeaderAPIVersion, v.Name)
+	if v.Deprecated != nil {
+		h.Set(HeaderDeprecation, "@"+strconv.FormatInt(v.Deprecated.Unix(), 10))
+	}
+	if v.Sunset != nil {
+		h.Set(HeaderSunset, v.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if v.Link != nil {
+		h.Add("Link", "<"+*(v.Link)+`>; rel="deprecation"`)
+	}
+
+	deprecated := v.Deprecated != nil && !now.Before(*(v.Deprecated))
+	vs.gin.metrics.versions.WithLabelValues(v.Name, c.FullPath(), strconv.FormatBool(deprecated)).Inc()
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), CKey("api_version"), v.Name))
+}
@@ -0,0 +1,459 @@
+package gin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	g "github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	ca "github.com/tossaro/go-api-core/auth"
+)
+
+const (
+	// SubprotocolBearer carries the access token in Sec-WebSocket-Protocol
+	// for browsers which cannot set headers: "bearer, <token>". Tokens
+	// are not read from the query string, it ends up in access logs.
+	SubprotocolBearer = "bearer"
+
+	_defaultPingInterval   = 30 * time.Second
+	_defaultWSWriteTimeout = 10 * time.Second
+	_defaultSendBuffer     = 64
+	_defaultMaxMessage     = 64 << 10
+	_hubChannel            = "ws"
+)
+
+// ErrConnClosed is returned by SendJSON when the connection is closed or
+// was dropped for falling behind.
+var ErrConnClosed = errors.New("websocket - connection closed")
+
+type (
+	HubOptions struct {
+		// PingInterval between heartbeats, a connection missing its pong
+		// for one more interval is closed.
+		PingInterval *time.Duration
+		WriteTimeout *time.Duration
+		// SendBuffer is the number of messages queued per connection, a
+		// connection falling behind is closed instead of slowing the hub.
+		SendBuffer *int
+		// MaxMessageSize of client messages in bytes.
+		MaxMessageSize *int64
+		// Channel of the Redis fan-out, default "ws:<BaseUrl>". Broadcasts
+		// reach other replicas when Options.Redis is set.
+		Channel *string
+	}
+
+	// Hub tracks the WebSocket connections of this replica by user and
+	// room.
+	Hub struct {
+		gin     *Gin
+		id      string
+		channel string
+		ping    time.Duration
+		write   time.Duration
+		buffer  int
+		max     int64
+		cancel  context.CancelFunc
+
+		mu    sync.RWMutex
+		conns map[*Conn]struct{}
+		users map[uint64]map[*Conn]struct{}
+		rooms map[string]map[*Conn]struct{}
+	}
+
+	// Conn is an authenticated WebSocket connection.
+	Conn struct {
+		hub       *Hub
+		ws        *websocket.Conn
+		ctx       context.Context
+		principal *ca.Principal
+		send      chan []byte
+		done      chan struct{}
+		closeOnce sync.Once
+		rooms     map[string]struct{}
+	}
+
+	WebSocketOptions struct {
+		Hub *Hub
+		// Roles allowed to connect, any when empty.
+		Roles []int32
+		// Origins allowed to connect, "*" for any. Default the request host
+		// only.
+		Origins []string
+		// OnConnect runs once the connection is registered, to join rooms.
+		OnConnect func(c *Conn)
+		// OnMessage runs for every client message, one at a time.
+		OnMessage func(c *Conn, msg []byte)
+		OnClose   func(c *Conn)
+	}
+
+	hubMessage struct {
+		Origin string  `json:"o"`
+		User   *uint64 `json:"u,omitempty"`
+		Room   string  `json:"r,omitempty"`
+		Data   []byte  `json:"d"`
+	}
+)
+
+// NewHub returns a hub fanning its broadcasts out through Redis when
+// Options.Redis is set, Close or Gin Shutdown stops it.
+func (gin *Gin) NewHub(o *HubOptions) *Hub {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	h := &Hub{
+		gin:     gin,
+		id:      hex.EncodeToString(b[:]),
+		channel: _hubChannel + ":" + gin.Options.BaseUrl,
+		ping:    _defaultPingInterval,
+		write:   _defaultWSWriteTimeout,
+		buffer:  _defaultSendBuffer,
+		max:     _defaultMaxMessage,
+		conns:   make(map[*Conn]struct{}),
+		users:   make(map[uint64]map[*Conn]struct{}),
+		rooms:   make(map[string]map[*Conn]struct{}),
+	}
+	if o.PingInterval != nil {
+		h.ping = *(o.PingInterval)
+	}
+	if o.WriteTimeout != nil {
+		h.write = *(o.WriteTimeout)
+	}
+	if o.SendBuffer != nil {
+		h.buffer = *(o.SendBuffer)
+	}
+	if o.MaxMessageSize != nil {
+		h.max = *(o.MaxMessageSize)
+	}
+	if o.Channel != nil {
+		h.channel = *(o.Channel)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	if gin.Options.Redis != nil {
+		msgs, err := gin.Options.Redis.Subscribe(ctx, h.channel)
+		if err != nil {
+			gin.Options.Log.Fatal("gin - NewHub subscribe: " + err.Error())
+		}
+		go h.receive(msgs)
+	}
+	go func() {
+		select {
+		case <-gin.Done():
+			h.Close()
+		case <-ctx.Done():
+		}
+	}()
+	return h
+}
+
+// WebSocket upgrades GET path on r for access tokens sent in the
+// Authorization header or the "bearer" subprotocol, checked by
+// AuthAccessMiddleware.
+func (gin *Gin) WebSocket(r *g.RouterGroup, path string, o *WebSocketOptions) {
+	if o.Hub == nil {
+		gin.Options.Log.Fatal("gin - WebSocket require Hub option")
+	}
+	up := websocket.Upgrader{CheckOrigin: checkOrigin(o.Origins)}
+	r.GET(path, wsToken, gin.AuthAccessMiddleware(o.Roles), func(c *g.Context) {
+		p, _ := PrincipalFrom(c)
+		var header http.Header
+		if bearerSubprotocol(c.Request) {
+			header = http.Header{"Sec-WebSocket-Protocol": {SubprotocolBearer}}
+		}
+		ws, err := up.Upgrade(c.Writer, c.Request, header)
+		if err != nil {
+			// Upgrade already answered the client
+			gin.Options.Log.Error("websocket", err)
+			return
+		}
+		o.Hub.serve(c.Request.Context(), ws, p, o)
+	})
+}
+
+// wsToken moves a subprotocol token into the Authorization
+// header so the auth middlewares read it as usual.
+func wsToken(c *g.Context) {
+	if c.GetHeader("Authorization") != "" {
+		return
+	}
+	protocols := websocket.Subprotocols(c.Request)
+	for i, p := range protocols {
+		if p == SubprotocolBearer && i+1 < len(protocols) {
+			c.Request.Header.Set("Authorization", "Bearer "+protocols[i+1])
+			return
+		}
+	}
+}
+
+func bearerSubprotocol(r *http.Request) bool {
+	for _, p := range websocket.Subprotocols(r) {
+		if p == SubprotocolBearer {
+			return true
+		}
+	}
+	return false
+}
+
+func checkOrigin(origins []string) func(r *http.Request) bool {
+	if len(origins) == 0 {
+		return nil
+	}
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		for _, o := range origins {
+			if o == "*" || strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func (h *Hub) serve(ctx context.Context, ws *websocket.Conn, p *ca.Principal, o *WebSocketOptions) {
+	c := &Conn{
+		hub:       h,
+		ws:        ws,
+		ctx:       ctx,
+		principal: p,
+		send:      make(chan []byte, h.buffer),
+		done:      make(chan struct{}),
+		rooms:     make(map[string]struct{}),
+	}
+	h.add(c)
+	defer func() {
+		h.remove(c)
+		c.Close()
+		if o.OnClose != nil {
+			o.OnClose(c)
+		}
+	}()
+	go c.writePump()
+	if o.OnConnect != nil {
+		o.OnConnect(c)
+	}
+
+	ws.SetReadLimit(h.max)
+	_ = ws.SetReadDeadline(time.Now().Add(2 * h.ping))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(2 * h.ping))
+	})
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				h.gin.Options.Log.Error("websocket", err)
+			}
+			return
+		}
+		if o.OnMessage != nil {
+			o.OnMessage(c, msg)
+		}
+	}
+}
+
+// writePump is the only writer of the connection, it sends the queued
+// messages and the heartbeats.
+func (c *Conn) writePump() {
+	t := time.NewTicker(c.hub.ping)
+	defer t.Stop()
+	defer c.ws.Close()
+	for {
+		select {
+		case <-c.done:
+			_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(c.hub.write))
+			return
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.hub.write))
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.Close()
+				return
+			}
+		case <-t.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.hub.write)); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// Context of the upgrade request, carrying the principal and tenant.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *Conn) Principal() *ca.Principal {
+	return c.principal
+}
+
+// Send queues msg, a connection whose queue is full is closed and false
+// returned.
+func (c *Conn) Send(msg []byte) bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		c.hub.gin.Options.Log.Warn("websocket - closing slow connection of user %d", c.principal.UID)
+		c.Close()
+		return false
+	}
+}
+
+func (c *Conn) SendJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if !c.Send(b) {
+		return ErrConnClosed
+	}
+	return nil
+}
+
+// Close ends the connection, it may be called more than once.
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+}
+
+func (c *Conn) Join(room string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	c.rooms[room] = struct{}{}
+	if c.hub.rooms[room] == nil {
+		c.hub.rooms[room] = make(map[*Conn]struct{})
+	}
+	c.hub.rooms[room][c] = struct{}{}
+}
+
+func (c *Conn) Leave(room string) {
+	c.hub.mu.Lock()
+	defer c.hub.mu.Unlock()
+	delete(c.rooms, room)
+	unset(c.hub.rooms, room, c)
+}
+
+func (h *Hub) add(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns[c] = struct{}{}
+	if h.users[c.principal.UID] == nil {
+		h.users[c.principal.UID] = make(map[*Conn]struct{})
+	}
+	h.users[c.principal.UID][c] = struct{}{}
+}
+
+func (h *Hub) remove(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, c)
+	unset(h.users, c.principal.UID, c)
+	for room := range c.rooms {
+		unset(h.rooms, room, c)
+	}
+}
+
+func unset[K comparable](m map[K]map[*Conn]struct{}, k K, c *Conn) {
+	delete(m[k], c)
+	if len(m[k]) == 0 {
+		delete(m, k)
+	}
+}
+
+// Broadcast sends msg to every connection of every replica.
+func (h *Hub) Broadcast(msg []byte) {
+	h.publish(hubMessage{Data: msg})
+}
+
+// BroadcastUser sends msg to the connections of user uid.
+func (h *Hub) BroadcastUser(uid uint64, msg []byte) {
+	h.publish(hubMessage{User: &uid, Data: msg})
+}
+
+// BroadcastRoom sends msg to the connections which joined room.
+func (h *Hub) BroadcastRoom(room string, msg []byte) {
+	h.publish(hubMessage{Room: room, Data: msg})
+}
+
+// Count returns the number of connections on this replica.
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// Close stops the Redis fan-out and closes every connection.
+func (h *Hub) Close() {
+	h.cancel()
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.conns {
+		c.Close()
+	}
+}
+
+func (h *Hub) publish(m hubMessage) {
+	h.deliver(m)
+	if h.gin.Options.Redis == nil {
+		return
+	}
+	m.Origin = h.id
+	b, err := json.Marshal(m)
+	if err != nil {
+		h.gin.Options.Log.Error("websocket", err)
+		return
+	}
+	if err := h.gin.Options.Redis.Publish(h.channel, b); err != nil {
+		h.gin.Options.Log.Error("websocket", err)
+	}
+}
+
+// receive delivers the broadcasts of the other replicas.
+func (h *Hub) receive(msgs <-chan string) {
+	for s := range msgs {
+		var m hubMessage
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			h.gin.Options.Log.Error("websocket", err)
+			continue
+		}
+		if m.Origin != h.id {
+			h.deliver(m)
+		}
+	}
+}
+
+func (h *Hub) deliver(m hubMessage) {
+	h.mu.RLock()
+	var targets []*Conn
+	switch {
+	case m.User != nil:
+		for c := range h.users[*(m.User)] {
+			targets = append(targets, c)
+		}
+	case m.Room != "":
+		for c := range h.rooms[m.Room] {
+			targets = append(targets, c)
+		}
+	default:
+		for c := range h.conns {
+			targets = append(targets, c)
+		}
+	}
+	h.mu.RUnlock()
+	for _, c := range targets {
+		c.Send(m.Data)
+	}
+}
@@ -0,0 +1,119 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+
+	g "github.com/gin-gonic/gin"
+)
+
+type (
+	// bodyWriter keeps a copy of everything written to the client. With a
+	// limit above zero the copy is dropped past limit bytes and truncated
+	// set, so streamed responses do not grow it without bound.
+	bodyWriter struct {
+		g.ResponseWriter
+		body      *bytes.Buffer
+		limit     int
+		truncated bool
+	}
+
+	// bufferWriter holds the whole response until flush, letting
+	// middlewares inspect or replace it before anything is sent.
+	bufferWriter struct {
+		g.ResponseWriter
+		status int
+		wrote  bool
+		body   bytes.Buffer
+	}
+)
+
+func (w *bodyWriter) Write(b []byte) (int, error) {
+	if w.keep(len(b)) {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyWriter) WriteString(s string) (int, error) {
+	if w.keep(len(s)) {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyWriter) keep(n int) bool {
+	if w.truncated {
+		return false
+	}
+	if w.limit > 0 && w.body.Len()+n > w.limit {
+		w.truncated = true
+		w.body = &bytes.Buffer{}
+		return false
+	}
+	return true
+}
+
+// Unwrap lets http.ResponseController reach the connection.
+func (w *bodyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func newBufferWriter(w g.ResponseWriter) *bufferWriter {
+	return &bufferWriter{ResponseWriter: w, status: http.StatusOK}
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if code > 0 {
+		w.status = code
+		w.wrote = true
+	}
+}
+
+func (w *bufferWriter) WriteHeaderNow() {}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.body.Write(b)
+}
+
+func (w *bufferWriter) WriteString(s string) (int, error) {
+	w.wrote = true
+	return w.body.WriteString(s)
+}
+
+func (w *bufferWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferWriter) Size() int {
+	return w.body.Len()
+}
+
+func (w *bufferWriter) Written() bool {
+	return false
+}
+
+func (w *bufferWriter) Flush() {}
+
+func (w *bufferWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// flush sends the buffered status and body, b replaces the body when not
+// nil. Nothing is sent when the handler did not respond, leaving it to
+// ErrorMiddleware.
+func (w *bufferWriter) flush(b []byte) {
+	if !w.wrote {
+		return
+	}
+	if b == nil {
+		b = w.body.Bytes()
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if len(b) > 0 {
+		_, _ = w.ResponseWriter.Write(b)
+	} else {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
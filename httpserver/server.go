@@ -71,6 +71,12 @@ func (s *Server) Notify() <-chan error {
 	return s.notify
 }
 
+// RegisterOnShutdown runs f when Shutdown starts, to end the long lived
+// connections Shutdown does not wait for.
+func (s *Server) RegisterOnShutdown(f func()) {
+	s.server.RegisterOnShutdown(f)
+}
+
 func (s *Server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
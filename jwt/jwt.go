@@ -33,6 +33,8 @@ type (
 		RoleId int32
 		Type   string
 		Key    *string
+		// Tenant is set by the Tenant* token methods, empty otherwise.
+		Tenant string `json:",omitempty"`
 		j.RegisteredClaims
 	}
 )
@@ -74,12 +76,13 @@ func NewRSA(o *Options) *Jwt {
 	return &Jwt{vk, ck, o}
 }
 
-func (jwt *Jwt) generateToken(typ string, exp int, uid uint64, rid int32, key *string, iss string) (string, error) {
+func (jwt *Jwt) generateToken(typ string, exp int, uid uint64, rid int32, key *string, tenant string, iss string) (string, error) {
 	c := TokenClaimsV1{
 		uid,
 		rid,
 		typ,
 		key,
+		tenant,
 		j.RegisteredClaims{
 			Issuer:    iss,
 			IssuedAt:  j.NewNumericDate(time.Now()),
@@ -91,7 +94,13 @@ func (jwt *Jwt) generateToken(typ string, exp int, uid uint64, rid int32, key *s
 }
 
 func (jwt *Jwt) AccessToken(uid uint64, rid int32, iss string) (*string, error) {
-	tk, err := jwt.generateToken("access", jwt.Options.AccessTokenLifetime, uid, rid, nil, iss)
+	return jwt.TenantAccessToken(uid, rid, "", iss)
+}
+
+// TenantAccessToken is AccessToken carrying the tenant claim read by
+// gin.TenantFromClaim.
+func (jwt *Jwt) TenantAccessToken(uid uint64, rid int32, tenant string, iss string) (*string, error) {
+	tk, err := jwt.generateToken("access", jwt.Options.AccessTokenLifetime, uid, rid, nil, tenant, iss)
 	if err != nil {
 		return nil, err
 	}
@@ -99,11 +108,15 @@ func (jwt *Jwt) AccessToken(uid uint64, rid int32, iss string) (*string, error)
 }
 
 func (jwt *Jwt) RefreshToken(uid uint64, rid int32, iss string) (*string, *string, error) {
+	return jwt.TenantRefreshToken(uid, rid, "", iss)
+}
+
+func (jwt *Jwt) TenantRefreshToken(uid uint64, rid int32, tenant string, iss string) (*string, *string, error) {
 	t := time.Unix(time.Now().UnixNano(), 0).String()
 	s := strconv.FormatUint(uint64(uid), 10) + t
 	k := hmac.New(sha256.New, []byte(s))
 	hk := hex.EncodeToString(k.Sum(nil))
-	tk, err := jwt.generateToken("refresh", jwt.Options.RefreshTokenLifetime, uid, rid, &hk, iss)
+	tk, err := jwt.generateToken("refresh", jwt.Options.RefreshTokenLifetime, uid, rid, &hk, tenant, iss)
 	if err != nil {
 		return nil, nil, err
 	}
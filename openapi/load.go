@@ -0,0 +1,257 @@
+package openapi
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	swagger2 struct {
+		Swagger     string                                   `json:"swagger"`
+		Info        Info                                     `json:"info"`
+		BasePath    string                                   `json:"basePath"`
+		Paths       map[string]map[string]*swagger2Operation `json:"paths"`
+		Definitions map[string]*Schema                       `json:"definitions"`
+	}
+
+	swagger2Operation struct {
+		OperationID string                      `json:"operationId"`
+		Summary     string                      `json:"summary"`
+		Description string                      `json:"description"`
+		Tags        []string                    `json:"tags"`
+		Consumes    []string                    `json:"consumes"`
+		Produces    []string                    `json:"produces"`
+		Parameters  []swagger2Parameter         `json:"parameters"`
+		Responses   map[string]swagger2Response `json:"responses"`
+		Deprecated  bool                        `json:"deprecated"`
+	}
+
+	// swagger2Parameter keeps the schema keywords inline, except for body
+	// parameters which carry a Schema.
+	swagger2Parameter struct {
+		Schema
+		Name     string  `json:"name"`
+		In       string  `json:"in"`
+		Required bool    `json:"required"`
+		Body     *Schema `json:"schema"`
+	}
+
+	swagger2Response struct {
+		Description string  `json:"description"`
+		Schema      *Schema `json:"schema"`
+	}
+)
+
+// Load reads a json or yaml document from path, see Parse.
+func Load(path string) (*Document, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var v interface{}
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	return Parse(b)
+}
+
+// Parse reads a json OpenAPI 3 document, or a Swagger 2.0 one such as
+// the swag generated docs which is converted, its basePath prefixing
+// every path.
+func Parse(b []byte) (*Document, error) {
+	var probe struct {
+		Swagger string `json:"swagger"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return nil, err
+	}
+	if probe.Swagger == "" {
+		d := &Document{}
+		if err := json.Unmarshal(b, d); err != nil {
+			return nil, err
+		}
+		return d, nil
+	}
+
+	var s swagger2
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	d := New(s.Info.Title, s.Info.Version)
+	d.Info = s.Info
+	for name, sc := range s.Definitions {
+		d.Components.Schemas[name] = sc
+	}
+	base := strings.TrimSuffix(s.BasePath, "/")
+	for p, item := range s.Paths {
+		pi := make(PathItem)
+		for method, op := range item {
+			pi[strings.ToLower(method)] = op.convert()
+		}
+		d.Paths[base+p] = pi
+	}
+	walk(d, func(sc *Schema) {
+		sc.Ref = strings.Replace(sc.Ref, "#/definitions/", _refPrefix, 1)
+	})
+	return d, nil
+}
+
+func (op *swagger2Operation) convert() *Operation {
+	o := &Operation{
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*Response),
+		Deprecated:  op.Deprecated,
+	}
+	consumes := "application/json"
+	if len(op.Consumes) > 0 {
+		consumes = op.Consumes[0]
+	}
+	produces := "application/json"
+	if len(op.Produces) > 0 {
+		produces = op.Produces[0]
+	}
+
+	for _, p := range op.Parameters {
+		if p.In == "body" {
+			o.RequestBody = &RequestBody{Required: p.Required, Content: map[string]MediaType{consumes: {Schema: p.Body}}}
+			continue
+		}
+		if p.In == "formData" {
+			continue
+		}
+		s := p.Schema
+		o.Parameters = append(o.Parameters, Parameter{Name: p.Name, In: p.In, Description: s.Description, Required: p.Required, Schema: &s})
+	}
+	for code, r := range op.Responses {
+		resp := &Response{Description: r.Description}
+		if r.Schema != nil {
+			resp.Content = map[string]MediaType{produces: {Schema: r.Schema}}
+		}
+		o.Responses[code] = resp
+	}
+	return o
+}
+
+// walk calls f on every schema of d.
+func walk(d *Document, f func(s *Schema)) {
+	var visit func(s *Schema)
+	visit = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		f(s)
+		for _, p := range s.Properties {
+			visit(p)
+		}
+		visit(s.Items)
+		visit(s.AdditionalProperties)
+	}
+	for _, s := range d.Components.Schemas {
+		visit(s)
+	}
+	for _, item := range d.Paths {
+		for _, op := range item {
+			for _, p := range op.Parameters {
+				visit(p.Schema)
+			}
+			if op.RequestBody != nil {
+				for _, m := range op.RequestBody.Content {
+					visit(m.Schema)
+				}
+			}
+			for _, r := range op.Responses {
+				for _, m := range r.Content {
+					visit(m.Schema)
+				}
+			}
+		}
+	}
+}
+
+// UnmarshalJSON accepts the schema variants of OpenAPI 3.0 and Swagger
+// 2.0: a type list, boolean exclusive bounds and boolean
+// additionalProperties.
+func (s *Schema) UnmarshalJSON(b []byte) error {
+	type plain Schema
+	var raw struct {
+		plain
+		Type                 interface{}     `json:"type"`
+		ExclusiveMinimum     interface{}     `json:"exclusiveMinimum"`
+		ExclusiveMaximum     interface{}     `json:"exclusiveMaximum"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*s = Schema(raw.plain)
+
+	switch t := raw.Type.(type) {
+	case string:
+		s.Type = t
+	case []interface{}:
+		for _, v := range t {
+			if n, ok := v.(string); ok && n != "null" {
+				s.Type = n
+				break
+			}
+		}
+	}
+	s.ExclusiveMinimum, s.Minimum = exclusive(raw.ExclusiveMinimum, s.Minimum)
+	s.ExclusiveMaximum, s.Maximum = exclusive(raw.ExclusiveMaximum, s.Maximum)
+	if len(raw.AdditionalProperties) > 0 && raw.AdditionalProperties[0] == '{' {
+		s.AdditionalProperties = &Schema{}
+		if err := json.Unmarshal(raw.AdditionalProperties, s.AdditionalProperties); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON reads the inline schema keywords apart, the Schema
+// method would otherwise take the whole parameter.
+func (p *swagger2Parameter) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	for k, v := range map[string]interface{}{"name": &p.Name, "in": &p.In, "required": &p.Required, "schema": &p.Body} {
+		if raw, ok := m[k]; ok {
+			if err := json.Unmarshal(raw, v); err != nil {
+				return err
+			}
+			delete(m, k)
+		}
+	}
+	rest, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(rest, &p.Schema)
+}
+
+// exclusive returns the exclusive and inclusive bounds, a boolean
+// exclusive flag turns the inclusive bound into the exclusive one.
+func exclusive(v interface{}, bound *float64) (*float64, *float64) {
+	switch e := v.(type) {
+	case float64:
+		return &e, bound
+	case bool:
+		if e {
+			return bound, nil
+		}
+	}
+	return nil, bound
+}
@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const _refPrefix = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generator derives schemas from Go types the way encoding/json and the
+// gin binding see them: json names, binding constraints, named structs
+// kept once in the document components.
+type Generator struct {
+	doc   *Document
+	names map[reflect.Type]string
+	used  map[string]reflect.Type
+}
+
+func NewGenerator(d *Document) *Generator {
+	return &Generator{doc: d, names: make(map[reflect.Type]string), used: make(map[string]reflect.Type)}
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, _refPrefix)
+}
+
+// Ref is the reference to the component schema name.
+func Ref(name string) *Schema {
+	return &Schema{Ref: _refPrefix + name}
+}
+
+// Schema returns the schema of t, a reference for named structs.
+func (gn *Generator) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", ContentEncoding: "base64"}
+		}
+		return &Schema{Type: "array", Items: gn.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: gn.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return gn.Object(t, nil)
+		}
+		return gn.component(t)
+	}
+	return &Schema{}
+}
+
+// Object is the inline schema of struct t limited to the fields keep
+// accepts, every field when keep is nil.
+func (gn *Generator) Object(t reflect.Type, keep func(f reflect.StructField) bool) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	gn.fields(s, t, keep)
+	return s
+}
+
+// Named adds the object of t limited by keep to the components under
+// name, for request bodies made of part of a struct.
+func (gn *Generator) Named(name string, t reflect.Type, keep func(f reflect.StructField) bool) *Schema {
+	gn.doc.Components.Schemas[name] = gn.Object(t, keep)
+	return Ref(name)
+}
+
+func (gn *Generator) component(t reflect.Type) *Schema {
+	if name, ok := gn.names[t]; ok {
+		return Ref(name)
+	}
+	name := schemaName(t.Name())
+	if other, ok := gn.used[name]; ok && other != t {
+		name = schemaName(path.Base(t.PkgPath()) + "." + t.Name())
+	}
+	gn.names[t] = name
+	gn.used[name] = t
+	// registered before its fields so recursive types end on the reference
+	gn.doc.Components.Schemas[name] = &Schema{}
+	*gn.doc.Components.Schemas[name] = *gn.Object(t, nil)
+	return Ref(name)
+}
+
+func (gn *Generator) fields(s *Schema, t reflect.Type, keep func(f reflect.StructField) bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			gn.fields(s, ft, keep)
+			continue
+		}
+		if !f.IsExported() || (keep != nil && !keep(f)) {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs, required := gn.Field(f)
+		s.Properties[name] = fs
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// Field returns the schema of f with its binding constraints and whether
+// binding requires it.
+func (gn *Generator) Field(f reflect.StructField) (*Schema, bool) {
+	s := gn.Schema(f.Type)
+	if s.Ref != "" {
+		return s, hasRule(f.Tag.Get("binding"), "required")
+	}
+	if d := f.Tag.Get("description"); d != "" {
+		s.Description = d
+	}
+	if e := f.Tag.Get("example"); e != "" {
+		s.Examples = []interface{}{enumValue(s, e)}
+	}
+	return s, Constrain(s, f.Tag.Get("binding"))
+}
+
+// Constrain applies the validator rules of a binding tag to s and reports
+// whether "required" is one of them. Rules after "dive" apply to slice
+// elements and are skipped.
+func Constrain(s *Schema, binding string) bool {
+	var required bool
+	for _, r := range strings.Split(binding, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(r), "=")
+		switch k {
+		case "dive":
+			return required
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "oneof":
+			for _, o := range strings.Fields(v) {
+				s.Enum = append(s.Enum, enumValue(s, o))
+			}
+		case "min", "gte":
+			bound(s, v, &s.Minimum, &s.MinLength, &s.MinItems)
+		case "max", "lte":
+			bound(s, v, &s.Maximum, &s.MaxLength, &s.MaxItems)
+		case "len":
+			bound(s, v, nil, &s.MinLength, &s.MinItems)
+			bound(s, v, nil, &s.MaxLength, &s.MaxItems)
+		case "gt":
+			if s.Type == "integer" || s.Type == "number" {
+				bound(s, v, &s.ExclusiveMinimum, nil, nil)
+			}
+		case "lt":
+			if s.Type == "integer" || s.Type == "number" {
+				bound(s, v, &s.ExclusiveMaximum, nil, nil)
+			}
+		}
+	}
+	return required
+}
+
+func bound(s *Schema, v string, num **float64, length **int, items **int) {
+	switch s.Type {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil && num != nil {
+			*num = &n
+		}
+	case "string":
+		if n, err := strconv.Atoi(v); err == nil && length != nil {
+			*length = &n
+		}
+	case "array":
+		if n, err := strconv.Atoi(v); err == nil && items != nil {
+			*items = &n
+		}
+	}
+}
+
+func enumValue(s *Schema, v string) interface{} {
+	switch s.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+func hasRule(binding string, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// schemaName keeps the characters allowed in component names, generic
+// types such as Page[pkg.User] become Page_pkg.User_.
+func schemaName(n string) string {
+	b := []byte(n)
+	for i, c := range b {
+		if !(c == '.' || c == '-' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
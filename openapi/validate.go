@@ -0,0 +1,298 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+	patterns sync.Map
+)
+
+// Violation is a value breaking a schema keyword. Rule is the keyword:
+// required, type, enum, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, minLength, maxLength, minItems, maxItems, pattern or
+// format, Param its value.
+type Violation struct {
+	// Field is the dotted path of the value, "items.0.name", empty for the
+	// value itself.
+	Field string
+	Rule  string
+	Param string
+}
+
+func (v Violation) Error() string {
+	if v.Param == "" {
+		return v.Field + ": " + v.Rule
+	}
+	return v.Field + ": " + v.Rule + " " + v.Param
+}
+
+// Operation finds the operation serving method on path, templated
+// segments such as {id} match any segment and literal ones win over them.
+// The path parameter values are returned with it.
+func (d *Document) Operation(method string, path string) (*Operation, map[string]string, bool) {
+	method = strings.ToLower(method)
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+
+	var (
+		found  *Operation
+		params map[string]string
+		best   = -1
+	)
+	for tmpl, item := range d.Paths {
+		op, ok := item[method]
+		if !ok {
+			continue
+		}
+		ts := strings.Split(strings.Trim(tmpl, "/"), "/")
+		if len(ts) != len(segs) {
+			continue
+		}
+		literal, vals := 0, make(map[string]string)
+		for i, t := range ts {
+			if strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}") {
+				vals[t[1:len(t)-1]] = segs[i]
+				continue
+			}
+			if t != segs[i] {
+				literal = -1
+				break
+			}
+			literal++
+		}
+		if literal > best {
+			found, params, best = op, vals, literal
+		}
+	}
+	return found, params, found != nil
+}
+
+// Validate checks v, a value decoded by encoding/json, against s. Null
+// values pass, absent properties are reported by "required" only.
+func (d *Document) Validate(s *Schema, v interface{}, field string) []Violation {
+	s = d.Resolve(s)
+	if s == nil || v == nil {
+		return nil
+	}
+	if !hasType(s.Type, v) {
+		return []Violation{{Field: field, Rule: "type", Param: s.Type}}
+	}
+
+	var vs []Violation
+	add := func(rule string, param interface{}) {
+		vs = append(vs, Violation{Field: field, Rule: rule, Param: fmt.Sprint(param)})
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		vals := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			vals[i] = fmt.Sprint(e)
+		}
+		add("enum", strings.Join(vals, " "))
+	}
+
+	switch t := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(t)
+		if s.MinLength != nil && n < *(s.MinLength) {
+			add("minLength", *(s.MinLength))
+		}
+		if s.MaxLength != nil && n > *(s.MaxLength) {
+			add("maxLength", *(s.MaxLength))
+		}
+		if s.Pattern != "" && !matches(s.Pattern, t) {
+			add("pattern", s.Pattern)
+		}
+		if s.Format != "" && !hasFormat(s.Format, t) {
+			add("format", s.Format)
+		}
+	case float64:
+		if s.Minimum != nil && t < *(s.Minimum) {
+			add("minimum", *(s.Minimum))
+		}
+		if s.Maximum != nil && t > *(s.Maximum) {
+			add("maximum", *(s.Maximum))
+		}
+		if s.ExclusiveMinimum != nil && t <= *(s.ExclusiveMinimum) {
+			add("exclusiveMinimum", *(s.ExclusiveMinimum))
+		}
+		if s.ExclusiveMaximum != nil && t >= *(s.ExclusiveMaximum) {
+			add("exclusiveMaximum", *(s.ExclusiveMaximum))
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(t) < *(s.MinItems) {
+			add("minItems", *(s.MinItems))
+		}
+		if s.MaxItems != nil && len(t) > *(s.MaxItems) {
+			add("maxItems", *(s.MaxItems))
+		}
+		if s.Items != nil {
+			for i, e := range t {
+				vs = append(vs, d.Validate(s.Items, e, join(field, strconv.Itoa(i)))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, r := range s.Required {
+			if _, ok := t[r]; !ok {
+				vs = append(vs, Violation{Field: join(field, r), Rule: "required"})
+			}
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			e := t[k]
+			if p, ok := s.Properties[k]; ok {
+				vs = append(vs, d.Validate(p, e, join(field, k))...)
+			} else if s.AdditionalProperties != nil {
+				vs = append(vs, d.Validate(s.AdditionalProperties, e, join(field, k))...)
+			}
+		}
+	}
+	return vs
+}
+
+// ValidateParameter checks the raw values of p, absent when values is
+// empty. They are converted to the schema type first, arrays taking every
+// value or a comma separated one.
+func (d *Document) ValidateParameter(p Parameter, values []string) []Violation {
+	if len(values) == 0 {
+		if p.Required {
+			return []Violation{{Field: p.Name, Rule: "required"}}
+		}
+		return nil
+	}
+	s := d.Resolve(p.Schema)
+	if s == nil {
+		return nil
+	}
+	if s.Type == "array" {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]interface{}, len(values))
+		for i, v := range values {
+			items[i] = Coerce(d.Resolve(s.Items), v)
+		}
+		return d.Validate(s, items, p.Name)
+	}
+	return d.Validate(s, Coerce(s, values[0]), p.Name)
+}
+
+// Coerce converts a raw parameter to the json value of s, values which do
+// not convert are kept as string and fail the type check.
+func Coerce(s *Schema, v string) interface{} {
+	if s == nil {
+		return v
+	}
+	switch s.Type {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+func hasType(typ string, v interface{}) bool {
+	switch typ {
+	case "":
+		return true
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "null":
+		return false
+	}
+	return true
+}
+
+func inEnum(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if e == v || fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(pattern string, v string) bool {
+	re, ok := patterns.Load(pattern)
+	if !ok {
+		c, err := regexp.Compile(pattern)
+		if err != nil {
+			// an invalid pattern is the document's fault, not the value's
+			return true
+		}
+		re, _ = patterns.LoadOrStore(pattern, c)
+	}
+	return re.(*regexp.Regexp).MatchString(v)
+}
+
+// hasFormat checks the formats the generator emits, unknown ones pass.
+func hasFormat(format string, v string) bool {
+	switch format {
+	case "email":
+		a, err := mail.ParseAddress(v)
+		return err == nil && a.Address == v
+	case "uuid":
+		return uuidPattern.MatchString(v)
+	case "uri", "url":
+		u, err := url.ParseRequestURI(v)
+		return err == nil && u.Scheme != ""
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	}
+	return true
+}
+
+func join(field string, k string) string {
+	if field == "" {
+		return k
+	}
+	return field + "." + k
+}
+
+// Decode reads a json body the way Validate expects it.
+func Decode(b []byte) (interface{}, error) {
+	var v interface{}
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
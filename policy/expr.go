@@ -0,0 +1,229 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type (
+	// Value resolves an operand of a Condition from the input.
+	Value func(in *Input) interface{}
+
+	// Condition is a rule predicate, built with the helpers below:
+	//
+	//	policy.Or(
+	//		policy.Eq(policy.Subject("uid"), policy.Resource("owner_id")),
+	//		policy.Eq(policy.Subject("branch_id"), policy.Resource("branch_id")),
+	//	)
+	Condition func(in *Input) bool
+)
+
+// Subject reads a principal attribute, dots walk nested maps.
+func Subject(path string) Value {
+	return func(in *Input) interface{} { return lookup(in.Subject, path) }
+}
+
+// Resource reads an attribute of the resource loaded by the handler.
+func Resource(path string) Value {
+	return func(in *Input) interface{} { return lookup(in.Resource, path) }
+}
+
+// Request reads a request attribute such as "method", "path" or "ip".
+func Request(path string) Value {
+	return func(in *Input) interface{} { return lookup(in.Request, path) }
+}
+
+// Action is the action being authorized.
+func Action() Value {
+	return func(in *Input) interface{} { return in.Action }
+}
+
+// Const is a literal operand.
+func Const(v interface{}) Value {
+	return func(*Input) interface{} { return v }
+}
+
+func lookup(m map[string]interface{}, path string) interface{} {
+	var cur interface{} = m
+	for _, k := range strings.Split(path, ".") {
+		mm, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		if cur, ok = mm[k]; !ok {
+			return nil
+		}
+	}
+	return cur
+}
+
+func Always() Condition {
+	return func(*Input) bool { return true }
+}
+
+func And(c ...Condition) Condition {
+	return func(in *Input) bool {
+		for _, f := range c {
+			if !f(in) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func Or(c ...Condition) Condition {
+	return func(in *Input) bool {
+		for _, f := range c {
+			if f(in) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func Not(c Condition) Condition {
+	return func(in *Input) bool { return !c(in) }
+}
+
+// Exists holds when the attribute is set and not nil.
+func Exists(v Value) Condition {
+	return func(in *Input) bool { return v(in) != nil }
+}
+
+// Eq compares numbers by value whatever their type, so an uint64 uid
+// equals an int owner id loaded from the database or the "7" of a path
+// param. Nil never equals.
+func Eq(a Value, b Value) Condition {
+	return func(in *Input) bool { return equal(a(in), b(in)) }
+}
+
+func Ne(a Value, b Value) Condition {
+	return func(in *Input) bool {
+		x, y := a(in), b(in)
+		return x != nil && y != nil && !equal(x, y)
+	}
+}
+
+func Gt(a Value, b Value) Condition {
+	return compare(a, b, func(c int) bool { return c > 0 })
+}
+
+func Gte(a Value, b Value) Condition {
+	return compare(a, b, func(c int) bool { return c >= 0 })
+}
+
+func Lt(a Value, b Value) Condition {
+	return compare(a, b, func(c int) bool { return c < 0 })
+}
+
+func Lte(a Value, b Value) Condition {
+	return compare(a, b, func(c int) bool { return c <= 0 })
+}
+
+// In holds when a equals one of the values, or one element of b when b
+// resolves to a slice.
+func In(a Value, b ...Value) Condition {
+	return func(in *Input) bool {
+		x := a(in)
+		for _, v := range b {
+			y := v(in)
+			rv := reflect.ValueOf(y)
+			if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+				for i := 0; i < rv.Len(); i++ {
+					if equal(x, rv.Index(i).Interface()) {
+						return true
+					}
+				}
+				continue
+			}
+			if equal(x, y) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func isFloat(v interface{}) bool {
+	switch v.(type) {
+	case float32, float64:
+		return true
+	}
+	return false
+}
+
+func equal(a interface{}, b interface{}) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	x, okx := number(a)
+	y, oky := number(b)
+	if okx && oky {
+		if isFloat(a) || isFloat(b) {
+			return x == y
+		}
+		// integers compare exactly, ids above 2^53 do not fit a float64
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	if okx != oky {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(a Value, b Value, f func(int) bool) Condition {
+	return func(in *Input) bool {
+		x, y := a(in), b(in)
+		if nx, ok := number(x); ok {
+			if ny, ok := number(y); ok {
+				switch {
+				case nx < ny:
+					return f(-1)
+				case nx > ny:
+					return f(1)
+				}
+				return f(0)
+			}
+			return false
+		}
+		sx, okx := x.(string)
+		sy, oky := y.(string)
+		if !okx || !oky {
+			return false
+		}
+		return f(strings.Compare(sx, sy))
+	}
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/tossaro/go-api-core/logger"
+	"github.com/tossaro/go-api-core/rbac"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const (
+	Allow Effect = "allow"
+	Deny  Effect = "deny"
+
+	_defaultRule = "default-deny"
+)
+
+var ErrNoRules = errors.New("policy - no rules provided")
+
+type (
+	Effect string
+
+	// Input is everything a rule may look at, Subject holds the principal
+	// attributes, Resource the attributes of what is being accessed.
+	Input struct {
+		Subject  map[string]interface{} `json:"subject"`
+		Action   string                 `json:"action"`
+		Resource map[string]interface{} `json:"resource,omitempty"`
+		Request  map[string]interface{} `json:"request,omitempty"`
+	}
+
+	// Rule applies to Actions, matched like rbac permissions ("orders:*"),
+	// when its condition holds. A nil When always holds.
+	Rule struct {
+		Name    string
+		Effect  Effect
+		Actions []string
+		When    Condition
+	}
+
+	Decision struct {
+		Allowed bool   `json:"allowed"`
+		Rule    string `json:"rule"`
+		Effect  Effect `json:"effect"`
+	}
+
+	Options struct {
+		Rules []Rule
+		// Attributes enriches the input before evaluation, e.g. loads the
+		// branch of the subject.
+		Attributes func(ctx context.Context, in *Input) error
+		// Log receives every decision, denials only unless LogAllowed. Of
+		// the subject only its "uid" and "role_id" are logged, the other
+		// attributes may carry session ids or token claims.
+		Log        logger.Interface
+		LogAllowed bool
+	}
+
+	Engine struct {
+		o *Options
+	}
+)
+
+func New(o *Options) *Engine {
+	if len(o.Rules) == 0 {
+		log.Fatal(ErrNoRules)
+	}
+	for _, r := range o.Rules {
+		if r.Name == "" || (r.Effect != Allow && r.Effect != Deny) {
+			log.Fatalf("policy - rule %q require Name and Effect", r.Name)
+		}
+	}
+	return &Engine{o: o}
+}
+
+// Evaluate decides on in with deny overrides: a matching deny rule wins
+// over allow rules, and nothing matching denies.
+func (e *Engine) Evaluate(ctx context.Context, in *Input) (Decision, error) {
+	span, ctx := tracing.StartSpan(ctx, "policy.Evaluate", tracing.KindCustom)
+	defer span.End()
+
+	if e.o.Attributes != nil {
+		if err := e.o.Attributes(ctx, in); err != nil {
+			return Decision{Rule: _defaultRule, Effect: Deny}, err
+		}
+	}
+
+	d := Decision{Rule: _defaultRule, Effect: Deny}
+	for _, r := range e.o.Rules {
+		if !r.applies(in) {
+			continue
+		}
+		if r.Effect == Deny {
+			d = Decision{Rule: r.Name, Effect: Deny}
+			break
+		}
+		if !d.Allowed {
+			d = Decision{Allowed: true, Rule: r.Name, Effect: Allow}
+		}
+	}
+
+	span.SetAttribute("policy.action", in.Action)
+	span.SetAttribute("policy.rule", d.Rule)
+	span.SetAttribute("policy.allowed", d.Allowed)
+	e.log(in, d)
+	return d, nil
+}
+
+// Allowed is Evaluate reduced to its outcome.
+func (e *Engine) Allowed(ctx context.Context, in *Input) (bool, error) {
+	d, err := e.Evaluate(ctx, in)
+	return d.Allowed, err
+}
+
+func (r *Rule) applies(in *Input) bool {
+	if len(r.Actions) > 0 && !rbac.Granted(r.Actions, in.Action) {
+		return false
+	}
+	return r.When == nil || r.When(in)
+}
+
+func (e *Engine) log(in *Input, d Decision) {
+	if e.o.Log == nil || (d.Allowed && !e.o.LogAllowed) {
+		return
+	}
+	b, err := json.Marshal(struct {
+		Decision
+		Subject  interface{}            `json:"subject,omitempty"`
+		Role     interface{}            `json:"role,omitempty"`
+		Action   string                 `json:"action"`
+		Resource map[string]interface{} `json:"resource,omitempty"`
+	}{d, in.Subject["uid"], in.Subject["role_id"], in.Action, in.Resource})
+	if err != nil {
+		e.o.Log.Error("policy", err)
+		return
+	}
+	e.o.Log.Info("policy - decision %s", b)
+}
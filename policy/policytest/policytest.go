@@ -0,0 +1,54 @@
+// Package policytest checks policy rules from service tests:
+//
+//	func TestOrderPolicy(t *testing.T) {
+//		policytest.Run(t, engine, []policytest.Case{
+//			{Name: "owner edits", Input: policytest.Input(7, "orders:edit", map[string]interface{}{"owner_id": 7}), Allow: true},
+//			{Name: "stranger edits", Input: policytest.Input(8, "orders:edit", map[string]interface{}{"owner_id": 7})},
+//		})
+//	}
+package policytest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tossaro/go-api-core/policy"
+)
+
+type Case struct {
+	Name  string
+	Input *policy.Input
+	Allow bool
+	// Rule, when set, must be the rule which decided.
+	Rule string
+}
+
+// Input builds an input for subject uid, more subject attributes can be
+// set on the returned value.
+func Input(uid interface{}, action string, resource map[string]interface{}) *policy.Input {
+	return &policy.Input{
+		Subject:  map[string]interface{}{"uid": uid},
+		Action:   action,
+		Resource: resource,
+	}
+}
+
+// Run evaluates every case as a subtest.
+func Run(t *testing.T, e *policy.Engine, cases []Case) {
+	t.Helper()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			d, err := e.Evaluate(context.Background(), tc.Input)
+			if err != nil {
+				t.Fatalf("evaluate: %v", err)
+			}
+			if d.Allowed != tc.Allow {
+				t.Errorf("allowed = %v by rule %q, want %v", d.Allowed, d.Rule, tc.Allow)
+			}
+			if tc.Rule != "" && d.Rule != tc.Rule {
+				t.Errorf("rule = %q, want %q", d.Rule, tc.Rule)
+			}
+		})
+	}
+}
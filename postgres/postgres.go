@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -14,6 +15,7 @@ const (
 	_defaultConnTimeout      = time.Second
 	_defaultMigrationsFolder = "migrations"
 	_defaultSeedsFolder      = "seeds"
+	_defaultTenantVariable   = "app.tenant_id"
 )
 
 type (
@@ -24,10 +26,19 @@ type (
 		ConnTimeout      *time.Duration
 		MigrationsFolder *string
 		SeedsFolder      *string
+		// TenantVariable is the session variable set by WithTenant, read by
+		// row level security policies, default app.tenant_id.
+		TenantVariable *string
+		// TenantSchema is the per tenant schema pattern such as "tenant_%s",
+		// when set WithTenant also puts it first in the search_path.
+		TenantSchema *string
 	}
 
 	Postgres struct {
 		Pool *pgxpool.Pool
+
+		tenantVariable string
+		tenantSchema   string
 	}
 )
 
@@ -46,6 +57,8 @@ func New(o *Options) *Postgres {
 		pMax = *(o.PoolMax)
 	}
 	poolConfig.MaxConns = int32(pMax)
+	poolConfig.ConnConfig.Logger = queryTracer{next: poolConfig.ConnConfig.Logger}
+	poolConfig.ConnConfig.LogLevel = pgx.LogLevelInfo
 
 	cAt := _defaultConnAttempts
 	if o.ConnAttempts != nil {
@@ -85,7 +98,16 @@ func New(o *Options) *Postgres {
 	migrate(o.Url+"?sslmode=disable", mF)
 	seeder(p, sF)
 
-	return &Postgres{p}
+	tV := _defaultTenantVariable
+	if o.TenantVariable != nil {
+		tV = *(o.TenantVariable)
+	}
+	var tS string
+	if o.TenantSchema != nil {
+		tS = *(o.TenantSchema)
+	}
+
+	return &Postgres{Pool: p, tenantVariable: tV, tenantSchema: tS}
 }
 
 func (p *Postgres) Close() {
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	OpEq     = "eq"
+	OpNe     = "ne"
+	OpGt     = "gt"
+	OpGte    = "gte"
+	OpLt     = "lt"
+	OpLte    = "lte"
+	OpLike   = "like"
+	OpIn     = "in"
+	OpIsNull = "null"
+)
+
+var operators = map[string]string{
+	OpEq:   "=",
+	OpNe:   "<>",
+	OpGt:   ">",
+	OpGte:  ">=",
+	OpLt:   "<",
+	OpLte:  "<=",
+	OpLike: "ILIKE",
+}
+
+type (
+	Filter struct {
+		Column string
+		Op     string
+		Value  interface{}
+	}
+
+	Sort struct {
+		Column string
+		Desc   bool
+	}
+
+	// ListQuery is applied on top of a base select, the base is wrapped as
+	// a sub query so filters and sorts work on its output columns.
+	// Columns are expected to be whitelisted by the caller, they are quoted
+	// but never bound as parameters.
+	ListQuery struct {
+		Filters []Filter
+		Sorts   []Sort
+		Limit   int
+		Offset  int
+		// After holds the values of Sorts columns of the last row already
+		// seen, used for keyset (cursor) pagination instead of Offset.
+		After []interface{}
+	}
+)
+
+// likeEscaper keeps client supplied wildcards literal in OpLike.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func quote(c string) string {
+	return pgx.Identifier{c}.Sanitize()
+}
+
+func (q *ListQuery) where(args []interface{}) (string, []interface{}) {
+	conds := make([]string, 0, len(q.Filters)+1)
+	param := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	for _, f := range q.Filters {
+		col := quote(f.Column)
+		switch f.Op {
+		case OpIn:
+			conds = append(conds, col+" = ANY("+param(f.Value)+")")
+		case OpIsNull:
+			if b, ok := f.Value.(bool); ok && !b {
+				conds = append(conds, col+" IS NOT NULL")
+			} else {
+				conds = append(conds, col+" IS NULL")
+			}
+		case OpLike:
+			conds = append(conds, col+" ILIKE "+param("%"+likeEscaper.Replace(fmt.Sprint(f.Value))+"%")+` ESCAPE '\'`)
+		default:
+			op, ok := operators[f.Op]
+			if !ok {
+				op = "="
+			}
+			conds = append(conds, col+" "+op+" "+param(f.Value))
+		}
+	}
+
+	if len(q.After) > 0 && len(q.After) <= len(q.Sorts) {
+		// (a > $1) OR (a = $1 AND b < $2) ... honoring each sort direction
+		ors := make([]string, 0, len(q.After))
+		for i := range q.After {
+			ands := make([]string, 0, i+1)
+			for j := 0; j < i; j++ {
+				ands = append(ands, quote(q.Sorts[j].Column)+" = "+param(q.After[j]))
+			}
+			op := ">"
+			if q.Sorts[i].Desc {
+				op = "<"
+			}
+			ands = append(ands, quote(q.Sorts[i].Column)+" "+op+" "+param(q.After[i]))
+			ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// Build returns the paginated select for base, args are the parameters
+// already used by base and are kept first.
+func (q *ListQuery) Build(base string, args ...interface{}) (string, []interface{}) {
+	w, args := q.where(args)
+	sql := "SELECT * FROM (" + base + ") AS q" + w
+
+	if len(q.Sorts) > 0 {
+		s := make([]string, len(q.Sorts))
+		for i, o := range q.Sorts {
+			s[i] = quote(o.Column)
+			if o.Desc {
+				s[i] += " DESC"
+			}
+		}
+		sql += " ORDER BY " + strings.Join(s, ", ")
+	}
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		sql += " LIMIT $" + strconv.Itoa(len(args))
+	}
+	if q.Offset > 0 && len(q.After) == 0 {
+		args = append(args, q.Offset)
+		sql += " OFFSET $" + strconv.Itoa(len(args))
+	}
+	return sql, args
+}
+
+// BuildCount returns the count of base rows matching the filters, ignoring
+// sorting and pagination.
+func (q *ListQuery) BuildCount(base string, args ...interface{}) (string, []interface{}) {
+	f := &ListQuery{Filters: q.Filters}
+	w, args := f.where(args)
+	return "SELECT count(*) FROM (" + base + ") AS q" + w, args
+}
+
+func (p *Postgres) List(ctx context.Context, q *ListQuery, base string, args ...interface{}) (pgx.Rows, error) {
+	sql, a := q.Build(base, args...)
+	return p.Pool.Query(ctx, sql, a...)
+}
+
+func (p *Postgres) Count(ctx context.Context, q *ListQuery, base string, args ...interface{}) (int64, error) {
+	sql, a := q.BuildCount(base, args...)
+	var n int64
+	err := p.Pool.QueryRow(ctx, sql, a...).Scan(&n)
+	return n, err
+}
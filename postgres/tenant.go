@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/tossaro/go-api-core/tenant"
+)
+
+var ErrNoTenant = errors.New("postgres - context has no tenant")
+
+// WithTenant runs fn in a transaction scoped to the tenant of ctx, committed
+// when fn returns nil. The scope only lasts for the transaction so pooled
+// connections never leak it. Row level security policies read the tenant
+// with current_setting:
+//
+//	ALTER TABLE orders ENABLE ROW LEVEL SECURITY;
+//	CREATE POLICY tenant_isolation ON orders
+//		USING (tenant_id = current_setting('app.tenant_id'));
+func (p *Postgres) WithTenant(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	id, ok := tenant.From(ctx)
+	if !ok {
+		return ErrNoTenant
+	}
+	return p.Pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if err := p.SetTenant(ctx, tx, id); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// SetTenant scopes a transaction the caller manages itself to tenant id,
+// setting the tenant variable and, with Options.TenantSchema, the
+// search_path.
+func (p *Postgres) SetTenant(ctx context.Context, tx pgx.Tx, id string) error {
+	if !tenant.Valid(id) {
+		return fmt.Errorf("postgres - invalid tenant %q", id)
+	}
+	if _, err := tx.Exec(ctx, "SELECT set_config($1, $2, true)", p.tenantVariable, id); err != nil {
+		return err
+	}
+	if p.tenantSchema == "" {
+		return nil
+	}
+	schema := pgx.Identifier{fmt.Sprintf(p.tenantSchema, id)}.Sanitize()
+	_, err := tx.Exec(ctx, "SELECT set_config('search_path', $1, true)", schema+", public")
+	return err
+}
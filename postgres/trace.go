@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+// queryTracer turns the pgx query logs into spans, pgx v4 has no tracer
+// hook but logs every statement with its duration and context.
+type queryTracer struct {
+	next pgx.Logger
+}
+
+func (t queryTracer) Log(ctx context.Context, level pgx.LogLevel, msg string, data map[string]interface{}) {
+	if t.next != nil {
+		t.next.Log(ctx, level, msg, data)
+	}
+	d, ok := data["time"].(time.Duration)
+	if !ok {
+		return
+	}
+	sql, _ := data["sql"].(string)
+
+	span, _ := tracing.StartSpanAt(ctx, spanName(msg, sql), tracing.KindPostgres, time.Now().Add(-d))
+	span.SetAttribute("db.system", "postgresql")
+	if sql != "" {
+		span.SetAttribute("db.statement", sql)
+	}
+	if err, ok := data["err"].(error); ok {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// spanName is the first keyword of sql, "SELECT" or "INSERT", falling back
+// to the pgx message such as "CopyFrom".
+func spanName(msg string, sql string) string {
+	sql = strings.TrimSpace(sql)
+	if sql == "" {
+		return "postgres " + msg
+	}
+	if i := strings.IndexAny(sql, " \n\t("); i > 0 {
+		sql = sql[:i]
+	}
+	return "postgres " + strings.ToUpper(sql)
+}
@@ -0,0 +1,54 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/tossaro/go-api-core/postgres"
+)
+
+const _defaultPermissionsQuery = "SELECT permission FROM role_permissions WHERE role_id::text = $1"
+
+// PostgresStore loads roles with PermissionsQuery, default reading the
+// role_permissions (role_id, permission) table, and InheritsQuery when
+// set, both selecting one text column for the role id in $1.
+type PostgresStore struct {
+	Postgres         *postgres.Postgres
+	PermissionsQuery *string
+	InheritsQuery    *string
+}
+
+func (s *PostgresStore) Role(ctx context.Context, id string) (*Role, error) {
+	q := _defaultPermissionsQuery
+	if s.PermissionsQuery != nil {
+		q = *(s.PermissionsQuery)
+	}
+	perms, err := s.strings(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	role := &Role{Permissions: perms}
+	if s.InheritsQuery != nil {
+		if role.Inherits, err = s.strings(ctx, *(s.InheritsQuery), id); err != nil {
+			return nil, err
+		}
+	}
+	return role, nil
+}
+
+func (s *PostgresStore) strings(ctx context.Context, q string, id string) ([]string, error) {
+	rows, err := s.Postgres.Pool.Query(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,241 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tenant"
+	"github.com/tossaro/go-api-core/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	Wildcard = "*"
+
+	_defaultPrefix = "rbac"
+	_defaultTTL    = 5 * time.Minute
+	_separator     = ":"
+)
+
+var ErrRoleNotFound = errors.New("rbac - role not found")
+
+type (
+	Role struct {
+		Permissions []string `json:"permissions" yaml:"permissions"`
+		// Inherits grants every permission of the listed role ids.
+		Inherits []string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+	}
+
+	// Store loads a role by id, the token role id formatted as string.
+	Store interface {
+		Role(ctx context.Context, id string) (*Role, error)
+	}
+
+	// Policy is a Store kept in memory, usually read with LoadFile.
+	Policy map[string]Role
+
+	Options struct {
+		Store Store
+		// Redis caches the resolved permissions of each role, without it
+		// the Store is asked on every check. Roles are shared by every
+		// tenant, so is their cache.
+		Redis  cr.Cacher
+		TTL    *time.Duration
+		Prefix *string
+	}
+
+	RBAC struct {
+		store  Store
+		redis  cr.Cacher
+		ttl    time.Duration
+		prefix string
+	}
+)
+
+func New(o *Options) *RBAC {
+	if o.Store == nil {
+		log.Fatal("rbac - Store option not provided")
+	}
+	ttl := _defaultTTL
+	if o.TTL != nil {
+		ttl = *(o.TTL)
+	}
+	prefix := _defaultPrefix
+	if o.Prefix != nil {
+		prefix = *(o.Prefix)
+	}
+	return &RBAC{store: o.Store, redis: o.Redis, ttl: ttl, prefix: prefix}
+}
+
+// LoadFile reads a Policy from a json or yaml file keyed by role id:
+//
+//	"1":
+//	  permissions: ["orders:read"]
+//	"2":
+//	  permissions: ["orders:*", "users"]
+//	  inherits: ["1"]
+func LoadFile(path string) (Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := Policy{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &p)
+	default:
+		err = json.Unmarshal(b, &p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p Policy) Role(_ context.Context, id string) (*Role, error) {
+	r, ok := p[id]
+	if !ok {
+		return nil, ErrRoleNotFound
+	}
+	return &r, nil
+}
+
+// Match reports whether the granted permission covers required. Segments
+// are separated by ":", "*" matches one segment or, as last segment, any
+// remaining ones, and a parent grants its children: "orders" covers
+// "orders:write".
+func Match(granted string, required string) bool {
+	gs := strings.Split(granted, _separator)
+	rs := strings.Split(required, _separator)
+	for i, g := range gs {
+		if g == Wildcard && i == len(gs)-1 {
+			return true
+		}
+		if i >= len(rs) || (g != Wildcard && g != rs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Permissions returns the permissions of the roles including inherited
+// ones, unknown roles have none.
+func (r *RBAC) Permissions(ctx context.Context, roles ...string) ([]string, error) {
+	seen := make(map[string]bool)
+	var perms []string
+	for _, id := range roles {
+		p, err := r.rolePermissions(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range p {
+			if !seen[v] {
+				seen[v] = true
+				perms = append(perms, v)
+			}
+		}
+	}
+	sort.Strings(perms)
+	return perms, nil
+}
+
+// Can reports whether the roles together hold every required permission.
+func (r *RBAC) Can(ctx context.Context, roles []string, required ...string) (bool, error) {
+	span, ctx := tracing.StartSpan(ctx, "rbac.Can", tracing.KindCustom)
+	defer span.End()
+
+	perms, err := r.Permissions(ctx, roles...)
+	if err != nil {
+		return false, err
+	}
+	for _, req := range required {
+		if !Granted(perms, req) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// Granted reports whether one of perms covers required.
+func Granted(perms []string, required string) bool {
+	for _, p := range perms {
+		if Match(p, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// Invalidate drops the cached permissions of roles for every tenant, call
+// it after changing them in the Store. Roles inheriting them are cached
+// separately and expire with the TTL.
+func (r *RBAC) Invalidate(ctx context.Context, roles ...string) error {
+	if r.redis == nil {
+		return nil
+	}
+	for _, id := range roles {
+		if err := r.cache(ctx).Delete(r.prefix, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cache is not scoped by the tenant of ctx, the roles are global.
+func (r *RBAC) cache(ctx context.Context) cr.Cacher {
+	return r.redis.WithContext(tenant.With(ctx, ""))
+}
+
+func (r *RBAC) rolePermissions(ctx context.Context, id string) ([]string, error) {
+	if r.redis != nil {
+		v, err := r.cache(ctx).Get(r.prefix, id)
+		var perms []string
+		if err == nil && json.Unmarshal([]byte(v), &perms) == nil {
+			return perms, nil
+		}
+	}
+
+	perms, err := r.resolve(ctx, id, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+	if r.redis != nil {
+		if b, err := json.Marshal(perms); err == nil {
+			// a failing cache only costs another Store lookup
+			_ = r.cache(ctx).Set(r.prefix, id, b, r.ttl)
+		}
+	}
+	return perms, nil
+}
+
+func (r *RBAC) resolve(ctx context.Context, id string, visited map[string]bool) ([]string, error) {
+	if visited[id] {
+		return nil, nil
+	}
+	visited[id] = true
+
+	role, err := r.store.Role(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+	perms := append([]string{}, role.Permissions...)
+	for _, parent := range role.Inherits {
+		p, err := r.resolve(ctx, parent, visited)
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, p...)
+	}
+	return perms, nil
+}
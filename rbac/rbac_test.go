@@ -0,0 +1,73 @@
+package rbac
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	cr "github.com/tossaro/go-api-core/redis"
+	"github.com/tossaro/go-api-core/tenant"
+)
+
+// memRedis keeps the tenant scoped keys of a Cacher in memory, the copies
+// returned by WithContext share them.
+type memRedis struct {
+	cr.Cacher
+	mu  *sync.Mutex
+	m   map[string]string
+	ctx context.Context
+}
+
+func (r *memRedis) key(k string, p string) string {
+	if id, ok := tenant.From(r.ctx); ok {
+		return "tenant:" + id + ":" + k + ":" + p
+	}
+	return k + ":" + p
+}
+
+func (r *memRedis) Set(k string, p string, v interface{}, d time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.m[r.key(k, p)] = string(v.([]byte))
+	return nil
+}
+
+func (r *memRedis) Get(k string, p string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.m[r.key(k, p)]
+	if !ok {
+		return "", cr.Nil
+	}
+	return v, nil
+}
+
+func (r *memRedis) Delete(k string, p string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.m, r.key(k, p))
+	return nil
+}
+
+func (r *memRedis) WithContext(ctx context.Context) cr.Cacher {
+	return &memRedis{mu: r.mu, m: r.m, ctx: ctx}
+}
+
+func TestInvalidateClearsEveryTenant(t *testing.T) {
+	p := Policy{"1": {Permissions: []string{"orders:read"}}}
+	r := New(&Options{Store: p, Redis: &memRedis{mu: &sync.Mutex{}, m: map[string]string{}}})
+	ctx := tenant.With(context.Background(), "acme")
+
+	if perms, err := r.Permissions(ctx, "1"); err != nil || !reflect.DeepEqual(perms, []string{"orders:read"}) {
+		t.Fatalf("Permissions = %v, %v", perms, err)
+	}
+	p["1"] = Role{Permissions: []string{"orders:write"}}
+	if err := r.Invalidate(context.Background(), "1"); err != nil {
+		t.Fatal(err)
+	}
+	if perms, err := r.Permissions(ctx, "1"); err != nil || !reflect.DeepEqual(perms, []string{"orders:write"}) {
+		t.Fatalf("Permissions after Invalidate = %v, %v, want the updated role", perms, err)
+	}
+}
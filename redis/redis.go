@@ -1,10 +1,14 @@
 package redis
 
 import (
+	"context"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis"
+	"github.com/tossaro/go-api-core/tenant"
+	"github.com/tossaro/go-api-core/tracing"
 )
 
 type (
@@ -19,10 +23,12 @@ type (
 
 	Redis struct {
 		Cache *redis.Client
+		ctx   context.Context
 	}
 
 	ClusterRedis struct {
 		Cache *redis.ClusterClient
+		ctx   context.Context
 	}
 )
 
@@ -31,12 +37,37 @@ type Cacher interface {
 	Delete(k string, p string) error
 	Get(k string, p string) (v string, err error)
 	Ttl(k string, p string) (t time.Duration, err error)
+	SetNX(k string, p string, v interface{}, d time.Duration) (ok bool, err error)
+	Incr(k string, p string, d time.Duration) (n int64, err error)
+	Eval(script string, keys []string, args ...interface{}) (v interface{}, err error)
+	Publish(channel string, message interface{}) error
+	// Subscribe delivers the messages published on channels until ctx is
+	// done, the returned channel is closed then.
+	Subscribe(ctx context.Context, channels ...string) (<-chan string, error)
+	// WithContext returns a Cacher tracing its commands as children of the
+	// span in ctx, its keys are prefixed by the tenant of ctx if any.
+	WithContext(ctx context.Context) Cacher
 }
 
+// incrScript sets the expiry with the first increment in one step, a
+// counter is never left without it. A counter missing it is fixed too.
+const incrScript = `
+local n = redis.call('INCR', KEYS[1])
+local ttl = tonumber(ARGV[1])
+if ttl > 0 and (n == 1 or redis.call('PTTL', KEYS[1]) == -1) then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return n
+`
+
+// Nil is returned by Get when the key does not exist.
+const Nil = redis.Nil
+
 const (
 	_defaultPoolSize    = 5
 	_defaultMinIdleConn = 15
 	_defaultPoolTimeout = 5 * time.Second
+	_tenantPrefix       = "tenant:"
 )
 
 func config(c *Options) *Options {
@@ -58,6 +89,37 @@ func config(c *Options) *Options {
 	return c
 }
 
+func span(ctx context.Context, cmd string, key string) tracing.Span {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s, _ := tracing.StartSpan(ctx, "redis "+cmd, tracing.KindRedis)
+	s.SetAttribute("db.system", "redis")
+	s.SetAttribute("db.statement", cmd+" "+key)
+	return s
+}
+
+// key joins k and p, prefixed with "tenant:<id>:" when ctx carries a
+// tenant so every tenant gets its own key space.
+func key(ctx context.Context, k string, p string) string {
+	if id, ok := tenant.From(ctx); ok {
+		return _tenantPrefix + id + ":" + k + ":" + p
+	}
+	return k + ":" + p
+}
+
+func scoped(ctx context.Context, keys []string) []string {
+	id, ok := tenant.From(ctx)
+	if !ok {
+		return keys
+	}
+	s := make([]string, len(keys))
+	for i, k := range keys {
+		s[i] = _tenantPrefix + id + ":" + k
+	}
+	return s
+}
+
 func NewRedis(o *Options) Cacher {
 	o = config(o)
 	return &Redis{
@@ -85,34 +147,23 @@ func NewClusterRedis(o *Options) Cacher {
 	}
 }
 
+// Set overwrites the key in one command, readers never see it missing.
 func (r ClusterRedis) Set(k string, p string, v interface{}, d time.Duration) error {
-	err := r.Delete(k, p)
-	if err != nil {
-		return err
-	}
-	if d != 0*time.Second {
-		_, err := r.Cache.SetNX(k+":"+p, v, d).Result()
-		if err != nil {
-			return err
-		}
-	} else {
-		_, err := r.Cache.Set(k+":"+p, v, 0).Result()
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	defer span(r.ctx, "SET", key(r.ctx, k, p)).End()
+	return r.Cache.Set(key(r.ctx, k, p), v, d).Err()
 }
 
 func (r ClusterRedis) Delete(k string, p string) error {
-	if cmd := r.Cache.Del(k + ":" + p); cmd.Err() != nil {
+	defer span(r.ctx, "DEL", key(r.ctx, k, p)).End()
+	if cmd := r.Cache.Del(key(r.ctx, k, p)); cmd.Err() != nil {
 		return cmd.Err()
 	}
 	return nil
 }
 
 func (r ClusterRedis) Get(k string, p string) (v string, err error) {
-	v, err = r.Cache.Get(k + ":" + p).Result()
+	defer span(r.ctx, "GET", key(r.ctx, k, p)).End()
+	v, err = r.Cache.Get(key(r.ctx, k, p)).Result()
 	if err != nil {
 		return v, err
 	}
@@ -120,41 +171,60 @@ func (r ClusterRedis) Get(k string, p string) (v string, err error) {
 }
 
 func (r ClusterRedis) Ttl(k string, p string) (t time.Duration, err error) {
-	if cmd := r.Cache.TTL(k + ":" + p); cmd.Err() != nil {
+	defer span(r.ctx, "TTL", key(r.ctx, k, p)).End()
+	if cmd := r.Cache.TTL(key(r.ctx, k, p)); cmd.Err() != nil {
 		return t, cmd.Err()
 	} else {
 		return cmd.Val(), nil
 	}
 }
 
+func (r ClusterRedis) SetNX(k string, p string, v interface{}, d time.Duration) (ok bool, err error) {
+	defer span(r.ctx, "SETNX", key(r.ctx, k, p)).End()
+	return r.Cache.SetNX(key(r.ctx, k, p), v, d).Result()
+}
+
+func (r ClusterRedis) Incr(k string, p string, d time.Duration) (n int64, err error) {
+	defer span(r.ctx, "INCR", key(r.ctx, k, p)).End()
+	return r.Cache.Eval(incrScript, []string{key(r.ctx, k, p)}, d.Milliseconds()).Int64()
+}
+
+func (r ClusterRedis) WithContext(ctx context.Context) Cacher {
+	r.ctx = ctx
+	return r
+}
+
+func (r ClusterRedis) Eval(script string, keys []string, args ...interface{}) (v interface{}, err error) {
+	defer span(r.ctx, "EVAL", strings.Join(scoped(r.ctx, keys), " ")).End()
+	return r.Cache.Eval(script, scoped(r.ctx, keys), args...).Result()
+}
+
+func (r ClusterRedis) Publish(channel string, message interface{}) error {
+	defer span(r.ctx, "PUBLISH", channel).End()
+	return r.Cache.Publish(channel, message).Err()
+}
+
+func (r ClusterRedis) Subscribe(ctx context.Context, channels ...string) (<-chan string, error) {
+	return subscribe(ctx, r.Cache.Subscribe(channels...))
+}
+
+// Set overwrites the key in one command, readers never see it missing.
 func (r Redis) Set(k string, p string, v interface{}, d time.Duration) error {
-	err := r.Delete(k, p)
-	if err != nil {
-		return err
-	}
-	if d != 0*time.Second {
-		_, err := r.Cache.SetNX(k+":"+p, v, d).Result()
-		if err != nil {
-			return err
-		}
-	} else {
-		_, err := r.Cache.Set(k+":"+p, v, 0).Result()
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	defer span(r.ctx, "SET", key(r.ctx, k, p)).End()
+	return r.Cache.Set(key(r.ctx, k, p), v, d).Err()
 }
 
 func (r Redis) Delete(k string, p string) error {
-	if cmd := r.Cache.Del(k + ":" + p); cmd.Err() != nil {
+	defer span(r.ctx, "DEL", key(r.ctx, k, p)).End()
+	if cmd := r.Cache.Del(key(r.ctx, k, p)); cmd.Err() != nil {
 		return cmd.Err()
 	}
 	return nil
 }
 
 func (r Redis) Get(k string, p string) (string, error) {
-	v, err := r.Cache.Get(k + ":" + p).Result()
+	defer span(r.ctx, "GET", key(r.ctx, k, p)).End()
+	v, err := r.Cache.Get(key(r.ctx, k, p)).Result()
 	if err != nil {
 		return v, err
 	}
@@ -162,10 +232,69 @@ func (r Redis) Get(k string, p string) (string, error) {
 }
 
 func (r Redis) Ttl(k string, p string) (t time.Duration, err error) {
-	cmd := r.Cache.TTL(k + ":" + p)
+	defer span(r.ctx, "TTL", key(r.ctx, k, p)).End()
+	cmd := r.Cache.TTL(key(r.ctx, k, p))
 	if err = cmd.Err(); err != nil {
 		return t, err
 	} else {
 		return cmd.Val(), nil
 	}
 }
+
+func (r Redis) SetNX(k string, p string, v interface{}, d time.Duration) (ok bool, err error) {
+	defer span(r.ctx, "SETNX", key(r.ctx, k, p)).End()
+	return r.Cache.SetNX(key(r.ctx, k, p), v, d).Result()
+}
+
+func (r Redis) Incr(k string, p string, d time.Duration) (n int64, err error) {
+	defer span(r.ctx, "INCR", key(r.ctx, k, p)).End()
+	return r.Cache.Eval(incrScript, []string{key(r.ctx, k, p)}, d.Milliseconds()).Int64()
+}
+
+func (r Redis) WithContext(ctx context.Context) Cacher {
+	r.ctx = ctx
+	return r
+}
+
+func (r Redis) Eval(script string, keys []string, args ...interface{}) (v interface{}, err error) {
+	defer span(r.ctx, "EVAL", strings.Join(scoped(r.ctx, keys), " ")).End()
+	return r.Cache.Eval(script, scoped(r.ctx, keys), args...).Result()
+}
+
+func (r Redis) Publish(channel string, message interface{}) error {
+	defer span(r.ctx, "PUBLISH", channel).End()
+	return r.Cache.Publish(channel, message).Err()
+}
+
+func (r Redis) Subscribe(ctx context.Context, channels ...string) (<-chan string, error) {
+	return subscribe(ctx, r.Cache.Subscribe(channels...))
+}
+
+func subscribe(ctx context.Context, ps *redis.PubSub) (<-chan string, error) {
+	if _, err := ps.Receive(); err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	in := ps.Channel()
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer ps.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case m, ok := <-in:
+				if !ok {
+					return
+				}
+				select {
+				case out <- m.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
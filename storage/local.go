@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// _defaultPresignMaxSize bounds presigned uploads without MaxSize.
+const _defaultPresignMaxSize = 10 << 20
+
+// ErrPresignUnsupported is returned by Local without BaseUrl and Secret.
+var ErrPresignUnsupported = errors.New("storage - presigned urls require BaseUrl and Secret options")
+
+type (
+	LocalOptions struct {
+		Root string
+		// BaseUrl where Local is served as http.Handler, the presigned urls
+		// point to it.
+		BaseUrl *string
+		// Secret signing the presigned urls.
+		Secret *string
+	}
+
+	// Local keeps objects as files under Root, for development and single
+	// node deployments.
+	Local struct {
+		root    string
+		baseUrl string
+		secret  []byte
+	}
+)
+
+func NewLocal(o *LocalOptions) *Local {
+	if o.Root == "" {
+		log.Fatal("storage - Root option not provided")
+	}
+	if err := os.MkdirAll(o.Root, 0o750); err != nil {
+		log.Fatalf("storage - create root error: %s", err)
+	}
+	l := &Local{root: o.Root}
+	if o.BaseUrl != nil {
+		l.baseUrl = *(o.BaseUrl)
+	}
+	if o.Secret != nil {
+		l.secret = []byte(*(o.Secret))
+	}
+	return l
+}
+
+func (l *Local) file(k string) string {
+	return filepath.Join(l.root, filepath.FromSlash(k))
+}
+
+func (l *Local) Put(ctx context.Context, k string, r io.Reader, o *PutOptions) (*Object, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	defer span(ctx, "PUT", k).End()
+	return l.put(k, r)
+}
+
+// put writes to a temporary file renamed once complete, readers never see
+// a partial object.
+func (l *Local) put(k string, r io.Reader) (*Object, error) {
+	name := l.file(k)
+	if err := os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return nil, err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(f.Name(), name); err != nil {
+		return nil, err
+	}
+	return &Object{Key: k, Size: n, ContentType: contentType(k), ETag: hex.EncodeToString(h.Sum(nil)), ModTime: time.Now()}, nil
+}
+
+func (l *Local) Get(ctx context.Context, k string) (io.ReadCloser, *Object, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer span(ctx, "GET", k).End()
+	f, err := os.Open(l.file(k))
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, fileObject(k, fi), nil
+}
+
+func (l *Local) Stat(ctx context.Context, k string) (*Object, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	defer span(ctx, "STAT", k).End()
+	fi, err := os.Stat(l.file(k))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return fileObject(k, fi), nil
+}
+
+func (l *Local) Delete(ctx context.Context, k string) error {
+	k, err := key(ctx, k)
+	if err != nil {
+		return err
+	}
+	defer span(ctx, "DELETE", k).End()
+	if err := os.Remove(l.file(k)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) PresignGet(ctx context.Context, k string, expiry time.Duration) (string, error) {
+	return l.presign(ctx, http.MethodGet, k, expiry, url.Values{})
+}
+
+// PresignPut signs the max size and content type into the url, ServeHTTP
+// rejects uploads exceeding them.
+func (l *Local) PresignPut(ctx context.Context, k string, expiry time.Duration, o *PresignOptions) (string, error) {
+	limit, ct := int64(_defaultPresignMaxSize), ""
+	if o != nil {
+		if o.MaxSize > 0 {
+			limit = o.MaxSize
+		}
+		ct = o.ContentType
+	}
+	return l.presign(ctx, http.MethodPut, k, expiry, url.Values{"max": {strconv.FormatInt(limit, 10)}, "type": {ct}})
+}
+
+func (l *Local) presign(ctx context.Context, method string, k string, expiry time.Duration, q url.Values) (string, error) {
+	if l.baseUrl == "" || len(l.secret) == 0 {
+		return "", ErrPresignUnsupported
+	}
+	k, err := key(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	q.Set("key", k)
+	q.Set("expires", strconv.FormatInt(time.Now().Add(expiry).Unix(), 10))
+	q.Set("signature", l.sign(method, q))
+	return l.baseUrl + "?" + q.Encode(), nil
+}
+
+// sign covers the method and every signed query value, so neither the
+// key nor the upload limits can be changed.
+func (l *Local) sign(method string, q url.Values) string {
+	m := hmac.New(sha256.New, l.secret)
+	m.Write([]byte(method + "\n" + q.Get("key") + "\n" + q.Get("expires") + "\n" + q.Get("max") + "\n" + q.Get("type")))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// ServeHTTP answers the presigned GET and PUT urls, mount it on BaseUrl.
+func (l *Local) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	k := q.Get("key")
+	e, err := strconv.ParseInt(q.Get("expires"), 10, 64)
+	if len(l.secret) == 0 || err != nil || time.Now().Unix() > e ||
+		!hmac.Equal([]byte(l.sign(r.Method, q)), []byte(q.Get("signature"))) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	// the key was scoped when signed
+	k, err = key(context.Background(), k)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		f, err := os.Open(l.file(k))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentType(k))
+		http.ServeContent(w, r, path.Base(k), fi.ModTime(), f)
+	case http.MethodPut:
+		limit, err := strconv.ParseInt(q.Get("max"), 10, 64)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		if ct := q.Get("type"); ct != "" && r.Header.Get("Content-Type") != ct {
+			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+			return
+		}
+		if _, err := l.put(k, http.MaxBytesReader(w, r.Body, limit)); err != nil {
+			var mbe *http.MaxBytesError
+			if errors.As(err, &mbe) {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func fileObject(k string, fi fs.FileInfo) *Object {
+	return &Object{Key: k, Size: fi.Size(), ContentType: contentType(k), ModTime: fi.ModTime()}
+}
+
+func contentType(k string) string {
+	if t := mime.TypeByExtension(path.Ext(k)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
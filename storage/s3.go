@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const (
+	_defaultRegion = "us-east-1"
+	// _defaultPartSize bounds the buffer of uploads of unknown size,
+	// minio-go would size it for a 5TiB object otherwise.
+	_defaultPartSize = 16 << 20
+	_minPartSize     = 5 << 20
+)
+
+type (
+	S3Options struct {
+		// Endpoint host such as "s3.amazonaws.com" or "localhost:9000" for
+		// a local MinIO.
+		Endpoint  string
+		AccessKey string
+		SecretKey string
+		Bucket    string
+		Region    *string
+		// Insecure uses http, for a local MinIO.
+		Insecure bool
+		// PathStyle addresses the bucket in the path instead of the host,
+		// required by most S3-compatible servers.
+		PathStyle bool
+		// CreateBucket creates Bucket at start when missing.
+		CreateBucket bool
+	}
+
+	S3 struct {
+		Client *minio.Client
+		bucket string
+	}
+)
+
+func NewS3(o *S3Options) *S3 {
+	if o.Endpoint == "" {
+		log.Fatal("storage - Endpoint option not provided")
+	}
+	if o.Bucket == "" {
+		log.Fatal("storage - Bucket option not provided")
+	}
+	region := _defaultRegion
+	if o.Region != nil {
+		region = *(o.Region)
+	}
+	lookup := minio.BucketLookupAuto
+	if o.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	c, err := minio.New(o.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(o.AccessKey, o.SecretKey, ""),
+		Secure:       !o.Insecure,
+		Region:       region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		log.Fatalf("storage - s3 client error: %s", err)
+	}
+	if o.CreateBucket {
+		ctx := context.Background()
+		ok, err := c.BucketExists(ctx, o.Bucket)
+		if err == nil && !ok {
+			err = c.MakeBucket(ctx, o.Bucket, minio.MakeBucketOptions{Region: region})
+		}
+		if err != nil {
+			log.Fatalf("storage - create bucket error: %s", err)
+		}
+	}
+	return &S3{Client: c, bucket: o.Bucket}
+}
+
+func (s *S3) Put(ctx context.Context, k string, r io.Reader, o *PutOptions) (*Object, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	defer span(ctx, "PUT", k).End()
+	size, opts := int64(-1), minio.PutObjectOptions{PartSize: _defaultPartSize}
+	if o != nil {
+		if o.Size > 0 {
+			size = o.Size
+		} else if o.MaxSize > 0 && o.MaxSize < _defaultPartSize {
+			// one part holds the whole content
+			opts.PartSize = uint64(max(o.MaxSize+1, _minPartSize))
+		}
+		opts.ContentType = o.ContentType
+		opts.UserMetadata = o.Metadata
+	}
+	info, err := s.Client.PutObject(ctx, s.bucket, k, r, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Object{Key: k, Size: info.Size, ContentType: opts.ContentType, ETag: info.ETag, ModTime: info.LastModified}, nil
+}
+
+func (s *S3) Get(ctx context.Context, k string) (io.ReadCloser, *Object, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer span(ctx, "GET", k).End()
+	obj, err := s.Client.GetObject(ctx, s.bucket, k, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(err)
+	}
+	// GetObject is lazy, Stat performs the request
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, s3Error(err)
+	}
+	return obj, s3Object(info), nil
+}
+
+func (s *S3) Stat(ctx context.Context, k string) (*Object, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	defer span(ctx, "STAT", k).End()
+	info, err := s.Client.StatObject(ctx, s.bucket, k, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return s3Object(info), nil
+}
+
+func (s *S3) Delete(ctx context.Context, k string) error {
+	k, err := key(ctx, k)
+	if err != nil {
+		return err
+	}
+	defer span(ctx, "DELETE", k).End()
+	return s.Client.RemoveObject(ctx, s.bucket, k, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) PresignGet(ctx context.Context, k string, expiry time.Duration) (string, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	u, err := s.Client.PresignedGetObject(ctx, s.bucket, k, expiry, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *S3) PresignPut(ctx context.Context, k string, expiry time.Duration, o *PresignOptions) (string, error) {
+	k, err := key(ctx, k)
+	if err != nil {
+		return "", err
+	}
+	var header http.Header
+	if o != nil && o.ContentType != "" {
+		header = http.Header{"Content-Type": {o.ContentType}}
+	}
+	u, err := s.Client.PresignHeader(ctx, http.MethodPut, s.bucket, k, expiry, nil, header)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func s3Object(info minio.ObjectInfo) *Object {
+	return &Object{Key: info.Key, Size: info.Size, ContentType: info.ContentType, ETag: info.ETag, ModTime: info.LastModified}
+}
+
+func s3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/tossaro/go-api-core/tenant"
+	"github.com/tossaro/go-api-core/tracing"
+)
+
+const _tenantPrefix = "tenant/"
+
+var (
+	// ErrNotFound is returned by Get and Stat for missing objects.
+	ErrNotFound = errors.New("storage - object not found")
+	// ErrInvalidKey is returned for keys escaping the storage root.
+	ErrInvalidKey = errors.New("storage - invalid key")
+)
+
+type (
+	Object struct {
+		// Key as stored, with its tenant prefix.
+		Key         string
+		Size        int64
+		ContentType string
+		ETag        string
+		ModTime     time.Time
+	}
+
+	PutOptions struct {
+		ContentType string
+		// Size of the content when known, it lets S3 skip the multipart
+		// upload of small objects.
+		Size int64
+		// MaxSize bounds a content of unknown Size, S3 sizes its part
+		// buffer from it.
+		MaxSize  int64
+		Metadata map[string]string
+	}
+
+	PresignOptions struct {
+		// MaxSize of the uploaded content, default 10MB. Only Local enforces
+		// it, S3 presigned PUT urls can not bound the size so keep their
+		// expiry short.
+		MaxSize int64
+		// ContentType the upload must be sent with, signed into the url.
+		ContentType string
+	}
+
+	// Storage keeps objects by key. Keys are scoped to the tenant of ctx
+	// like Redis keys, "tenant/<id>/<key>".
+	Storage interface {
+		Put(ctx context.Context, key string, r io.Reader, o *PutOptions) (*Object, error)
+		Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+		Stat(ctx context.Context, key string) (*Object, error)
+		Delete(ctx context.Context, key string) error
+		// PresignGet returns a URL downloading key without credentials
+		// until expiry.
+		PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+		// PresignPut returns a URL uploading key with a PUT request until
+		// expiry, o may be nil.
+		PresignPut(ctx context.Context, key string, expiry time.Duration, o *PresignOptions) (string, error)
+	}
+)
+
+// key cleans k and prefixes it with the tenant of ctx.
+func key(ctx context.Context, k string) (string, error) {
+	k = strings.TrimPrefix(path.Clean("/"+k), "/")
+	if k == "" || k == "." {
+		return "", ErrInvalidKey
+	}
+	if id, ok := tenant.From(ctx); ok {
+		return _tenantPrefix + id + "/" + k, nil
+	}
+	return k, nil
+}
+
+func span(ctx context.Context, op string, k string) tracing.Span {
+	s, _ := tracing.StartSpan(ctx, "storage "+op, tracing.KindStorage)
+	s.SetAttribute("storage.key", k)
+	return s
+}
@@ -0,0 +1,38 @@
+package tenant
+
+import "context"
+
+const _maxLength = 63
+
+type tenantKey struct{}
+
+// With returns a copy of ctx scoped to tenant id, Redis keys and Postgres
+// sessions derived from it are isolated per tenant. An empty id removes
+// the scope, e.g. to reach keys shared by every tenant.
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// From returns the tenant of ctx, false when there is none.
+func From(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, _ := ctx.Value(tenantKey{}).(string)
+	return id, id != ""
+}
+
+// Valid reports whether id is usable as tenant: lowercase letters, digits,
+// '-' and '_' up to 63 characters, so it fits a Postgres schema name and a
+// Redis key segment.
+func Valid(id string) bool {
+	if id == "" || len(id) > _maxLength {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.elastic.co/apm"
+	"go.elastic.co/apm/module/apmhttp"
+)
+
+const _attrStatusCode = "http.status_code"
+
+type (
+	apmTracer struct {
+		t *apm.Tracer
+	}
+
+	apmSpan struct {
+		ctx  context.Context
+		tx   *apm.Transaction
+		span *apm.Span
+	}
+)
+
+// newAPM uses apm.DefaultTracer configured by the ELASTIC_APM_* env, with
+// own replaces it by a tracer for o.ServiceName so apm helpers of other
+// libraries report to the same service.
+func newAPM(o *Options, own bool) *apmTracer {
+	if !own || o.ServiceName == "" {
+		return &apmTracer{}
+	}
+	t, err := apm.NewTracerOptions(apm.TracerOptions{
+		ServiceName:        o.ServiceName,
+		ServiceVersion:     o.ServiceVersion,
+		ServiceEnvironment: o.Environment,
+	})
+	if err != nil {
+		log.Fatalf("tracing - apm tracer error: %v", err)
+	}
+	if o.SampleRatio != nil {
+		t.SetSampler(apm.NewRatioSampler(*(o.SampleRatio)))
+	}
+	old := apm.DefaultTracer
+	apm.DefaultTracer = t
+	old.Close()
+	return &apmTracer{t: t}
+}
+
+func (a *apmTracer) tracer() *apm.Tracer {
+	if a.t != nil {
+		return a.t
+	}
+	return apm.DefaultTracer
+}
+
+func (a *apmTracer) StartRequest(ctx context.Context, name string, c Carrier) (Span, context.Context) {
+	var opts apm.TransactionOptions
+	if tc, err := apmhttp.ParseTraceparentHeader(c.Get(HeaderTraceparent)); err == nil {
+		if ts := c.Get(HeaderTracestate); ts != "" {
+			if state, err := apmhttp.ParseTracestateHeader(ts); err == nil {
+				tc.State = state
+			}
+		}
+		opts.TraceContext = tc
+	}
+	tx := a.tracer().StartTransactionOptions(name, KindRequest, opts)
+	ctx = apm.ContextWithTransaction(ctx, tx)
+	return &apmSpan{ctx: ctx, tx: tx}, ctx
+}
+
+func (a *apmTracer) StartSpan(ctx context.Context, name string, kind string, start time.Time) (Span, context.Context) {
+	s, ctx := apm.StartSpanOptions(ctx, name, kind, apm.SpanOptions{Start: start})
+	return &apmSpan{ctx: ctx, span: s}, ctx
+}
+
+func (a *apmTracer) SpanFromContext(ctx context.Context) Span {
+	if s := apm.SpanFromContext(ctx); s != nil {
+		return &apmSpan{ctx: ctx, span: s}
+	}
+	if tx := apm.TransactionFromContext(ctx); tx != nil {
+		return &apmSpan{ctx: ctx, tx: tx}
+	}
+	return noopSpan{}
+}
+
+func (a *apmTracer) Inject(ctx context.Context, c Carrier) {
+	var tc apm.TraceContext
+	if s := apm.SpanFromContext(ctx); s != nil && !s.Dropped() {
+		tc = s.TraceContext()
+	} else if tx := apm.TransactionFromContext(ctx); tx != nil {
+		tc = tx.TraceContext()
+	} else {
+		return
+	}
+	c.Set(HeaderTraceparent, apmhttp.FormatTraceparentHeader(tc))
+	if ts := tc.State.String(); ts != "" {
+		c.Set(HeaderTracestate, ts)
+	}
+}
+
+func (a *apmTracer) Shutdown(ctx context.Context) error {
+	t := a.tracer()
+	t.Flush(ctx.Done())
+	if a.t != nil {
+		t.Close()
+	}
+	return ctx.Err()
+}
+
+func (s *apmSpan) End() {
+	if s.span != nil {
+		s.span.End()
+		return
+	}
+	s.tx.End()
+}
+
+func (s *apmSpan) SetAttribute(key string, value interface{}) {
+	if s.span != nil {
+		if s.span.Dropped() {
+			return
+		}
+		if code, ok := value.(int); ok && key == _attrStatusCode {
+			s.span.Context.SetHTTPStatusCode(code)
+			return
+		}
+		s.span.Context.SetLabel(key, value)
+		return
+	}
+	if code, ok := value.(int); ok && key == _attrStatusCode {
+		s.tx.Result = apmhttp.StatusCodeResult(code)
+		s.tx.Context.SetHTTPStatusCode(code)
+		return
+	}
+	s.tx.Context.SetLabel(key, value)
+}
+
+func (s *apmSpan) RecordError(err error) {
+	if e := apm.CaptureError(s.ctx, err); e != nil {
+		e.Send()
+	}
+}
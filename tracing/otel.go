@@ -0,0 +1,155 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const _instrumentation = "github.com/tossaro/go-api-core"
+
+type (
+	otelTracer struct {
+		p    *sdktrace.TracerProvider
+		t    trace.Tracer
+		prop propagation.TextMapPropagator
+	}
+
+	otelSpan struct {
+		s trace.Span
+	}
+
+	// textMapCarrier adapts Carrier to propagation.TextMapCarrier.
+	textMapCarrier struct {
+		Carrier
+	}
+)
+
+func newOTLP(o *Options) (*otelTracer, error) {
+	var opts []otlptracehttp.Option
+	if o.Endpoint != nil {
+		opts = append(opts, otlptracehttp.WithEndpoint(*(o.Endpoint)))
+	}
+	if o.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exp, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := []attribute.KeyValue{attribute.String("service.name", o.ServiceName)}
+	if o.ServiceVersion != "" {
+		attrs = append(attrs, attribute.String("service.version", o.ServiceVersion))
+	}
+	if o.Environment != "" {
+		attrs = append(attrs, attribute.String("deployment.environment", o.Environment))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := _defaultSampleRatio
+	if o.SampleRatio != nil {
+		ratio = *(o.SampleRatio)
+	}
+	p := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	prop := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	otel.SetTracerProvider(p)
+	otel.SetTextMapPropagator(prop)
+
+	return &otelTracer{p: p, t: p.Tracer(_instrumentation), prop: prop}, nil
+}
+
+func spanKind(kind string) trace.SpanKind {
+	switch {
+	case kind == KindRequest:
+		return trace.SpanKindServer
+	case strings.HasPrefix(kind, "db."), strings.HasPrefix(kind, "external."):
+		return trace.SpanKindClient
+	default:
+		return trace.SpanKindInternal
+	}
+}
+
+func (o *otelTracer) StartRequest(ctx context.Context, name string, c Carrier) (Span, context.Context) {
+	ctx = o.prop.Extract(ctx, textMapCarrier{c})
+	ctx, s := o.t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+	return &otelSpan{s: s}, ctx
+}
+
+func (o *otelTracer) StartSpan(ctx context.Context, name string, kind string, start time.Time) (Span, context.Context) {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(spanKind(kind)),
+		trace.WithAttributes(attribute.String("span.type", kind)),
+	}
+	if !start.IsZero() {
+		opts = append(opts, trace.WithTimestamp(start))
+	}
+	ctx, s := o.t.Start(ctx, name, opts...)
+	return &otelSpan{s: s}, ctx
+}
+
+func (o *otelTracer) SpanFromContext(ctx context.Context) Span {
+	return &otelSpan{s: trace.SpanFromContext(ctx)}
+}
+
+func (o *otelTracer) Inject(ctx context.Context, c Carrier) {
+	o.prop.Inject(ctx, textMapCarrier{c})
+}
+
+func (o *otelTracer) Shutdown(ctx context.Context) error {
+	return o.p.Shutdown(ctx)
+}
+
+func (s *otelSpan) End() {
+	s.s.End()
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	var kv attribute.KeyValue
+	switch v := value.(type) {
+	case string:
+		kv = attribute.String(key, v)
+	case int:
+		kv = attribute.Int(key, v)
+		if key == _attrStatusCode && v >= 500 {
+			s.s.SetStatus(codes.Error, "")
+		}
+	case int64:
+		kv = attribute.Int64(key, v)
+	case uint64:
+		kv = attribute.Int64(key, int64(v))
+	case float64:
+		kv = attribute.Float64(key, v)
+	case bool:
+		kv = attribute.Bool(key, v)
+	default:
+		kv = attribute.String(key, fmt.Sprint(v))
+	}
+	s.s.SetAttributes(kv)
+}
+
+func (s *otelSpan) RecordError(err error) {
+	s.s.RecordError(err)
+	s.s.SetStatus(codes.Error, err.Error())
+}
+
+func (textMapCarrier) Keys() []string {
+	return nil
+}
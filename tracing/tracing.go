@@ -0,0 +1,188 @@
+package tracing
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tossaro/go-api-core/config"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	ProviderAPM  = "apm"
+	ProviderOTLP = "otlp"
+	ProviderNone = "none"
+
+	HeaderTraceparent = "traceparent"
+	HeaderTracestate  = "tracestate"
+
+	KindRequest  = "request"
+	KindCustom   = "custom"
+	KindPostgres = "db.postgresql.query"
+	KindRedis    = "db.redis"
+	KindGrpc     = "external.grpc"
+	KindStorage  = "external.storage"
+
+	_defaultSampleRatio = 1.0
+)
+
+type (
+	Options struct {
+		// Provider selects the exporter, ProviderAPM (default), ProviderOTLP
+		// or ProviderNone.
+		Provider       string
+		ServiceName    string
+		ServiceVersion string
+		Environment    string
+		// Endpoint of the OTLP collector as host:port, default taken from
+		// OTEL_EXPORTER_OTLP_ENDPOINT. APM reads ELASTIC_APM_SERVER_URL.
+		Endpoint    *string
+		Insecure    bool
+		SampleRatio *float64
+	}
+
+	Span interface {
+		End()
+		SetAttribute(key string, value interface{})
+		RecordError(err error)
+	}
+
+	// Carrier reads and writes the W3C trace context headers, see
+	// HeaderCarrier and MetadataCarrier.
+	Carrier interface {
+		Get(key string) string
+		Set(key string, value string)
+	}
+
+	Tracer interface {
+		// StartRequest begins the root span of an incoming request,
+		// continuing the trace found in c.
+		StartRequest(ctx context.Context, name string, c Carrier) (Span, context.Context)
+		// StartSpan begins a child span, a zero start means now.
+		StartSpan(ctx context.Context, name string, kind string, start time.Time) (Span, context.Context)
+		SpanFromContext(ctx context.Context) Span
+		// Inject writes the trace context of ctx into c for outgoing calls.
+		Inject(ctx context.Context, c Carrier)
+		Shutdown(ctx context.Context) error
+	}
+
+	HeaderCarrier   http.Header
+	MetadataCarrier metadata.MD
+
+	noopTracer struct{}
+	noopSpan   struct{}
+)
+
+var (
+	_mu      sync.RWMutex
+	_default Tracer = newAPM(&Options{}, false)
+)
+
+// NewOptions maps the TRACING_* config, the service is named after
+// config.App.Name.
+func NewOptions(cfg config.Config) *Options {
+	o := &Options{
+		Provider:       cfg.Tracing.Provider,
+		ServiceName:    cfg.App.Name,
+		ServiceVersion: cfg.App.Version,
+		Insecure:       cfg.Tracing.Insecure,
+		SampleRatio:    cfg.Tracing.SampleRatio,
+	}
+	if cfg.Tracing.Endpoint != "" {
+		o.Endpoint = &cfg.Tracing.Endpoint
+	}
+	return o
+}
+
+// New creates the tracer selected by o.Provider.
+func New(o *Options) Tracer {
+	switch o.Provider {
+	case ProviderNone:
+		return noopTracer{}
+	case ProviderOTLP:
+		t, err := newOTLP(o)
+		if err != nil {
+			log.Fatalf("tracing - otlp exporter error: %v", err)
+		}
+		return t
+	case ProviderAPM, "":
+		return newAPM(o, true)
+	default:
+		log.Fatalf("tracing - unknown provider %q", o.Provider)
+	}
+	return nil
+}
+
+// SetDefault replaces the tracer used by the package level helpers, the
+// Elastic APM default tracer is used until then.
+func SetDefault(t Tracer) {
+	_mu.Lock()
+	defer _mu.Unlock()
+	_default = t
+}
+
+func Default() Tracer {
+	_mu.RLock()
+	defer _mu.RUnlock()
+	return _default
+}
+
+func StartSpan(ctx context.Context, name string, kind string) (Span, context.Context) {
+	return Default().StartSpan(ctx, name, kind, time.Time{})
+}
+
+// StartSpanAt starts a span in the past, for operations only reported
+// once done such as pgx query logs.
+func StartSpanAt(ctx context.Context, name string, kind string, start time.Time) (Span, context.Context) {
+	return Default().StartSpan(ctx, name, kind, start)
+}
+
+func SpanFromContext(ctx context.Context) Span {
+	return Default().SpanFromContext(ctx)
+}
+
+func Inject(ctx context.Context, c Carrier) {
+	Default().Inject(ctx, c)
+}
+
+func (h HeaderCarrier) Get(key string) string {
+	return http.Header(h).Get(key)
+}
+
+func (h HeaderCarrier) Set(key string, value string) {
+	http.Header(h).Set(key, value)
+}
+
+func (m MetadataCarrier) Get(key string) string {
+	if v := metadata.MD(m).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (m MetadataCarrier) Set(key string, value string) {
+	metadata.MD(m).Set(key, value)
+}
+
+func (noopTracer) StartRequest(ctx context.Context, _ string, _ Carrier) (Span, context.Context) {
+	return noopSpan{}, ctx
+}
+
+func (noopTracer) StartSpan(ctx context.Context, _ string, _ string, _ time.Time) (Span, context.Context) {
+	return noopSpan{}, ctx
+}
+
+func (noopTracer) SpanFromContext(context.Context) Span { return noopSpan{} }
+
+func (noopTracer) Inject(context.Context, Carrier) {}
+
+func (noopTracer) Shutdown(context.Context) error { return nil }
+
+func (noopSpan) End() {}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) RecordError(error) {}